@@ -7,7 +7,7 @@ go build -o blockfycodes main.go
 # 检查构建结果
 if [ $? -eq 0 ]; then
     echo "构建成功，可执行文件: blockfycodes"
-    echo "用法: ./blockfycodes <文件路径或代码内容>"
+    echo "用法: ./blockfycodes [-src] <文件路径|->"
 else
     echo "构建失败"
     exit 1
@@ -2,21 +2,36 @@ package main
 
 import (
 	"bytes"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/printer"
 	"go/token"
+	"io"
 	"log"
 	"os"
 	"slices"
 
+	"github.com/monshunter/ast-practice/pkg/generated"
 	"golang.org/x/tools/go/ast/astutil"
 )
 
 func main() {
 	content := initConfig()
-	content, err := runInsertImport(content)
+	skip, err := genMode.Check(flag.Arg(0), generated.IsGeneratedSource(content))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if skip {
+		// 生成文件原样输出
+		fmt.Println(string(content))
+		return
+	}
+	if genMode == generated.Mark && generated.IsGeneratedSource(content) {
+		fmt.Fprintln(os.Stderr, "警告: 输入是生成文件，重新生成后插入的代码将会丢失")
+	}
+	content, err = runInsertImport(content)
 	if err != nil {
 		log.Fatalf("Failed to insert import: %v", err)
 	}
@@ -35,26 +50,45 @@ func main() {
 	fmt.Println(string(content))
 }
 
+// 命令行参数
+var (
+	srcMode bool
+	genMode = generated.Refuse
+)
+
+func init() {
+	flag.BoolVar(&srcMode, "src", false, "将参数视为Go源代码而不是文件路径")
+	flag.Var(&genMode, "generated", "生成文件的处理方式: refuse 报错退出、skip 原样输出、mark 警告后照常插入")
+}
+
 func initConfig() []byte {
-	var content []byte
+	flag.Parse()
 	// 检查参数
-	if len(os.Args) != 2 {
-		fmt.Println("用法: blockfycodes <文件路径或代码内容>")
+	if flag.NArg() != 1 {
+		fmt.Println("用法: blockfycodes [-src] [-generated refuse|skip|mark] <文件路径|->")
 		os.Exit(1)
 	}
 
-	input := os.Args[1]
-	// 判断输入是文件路径还是代码内容
-	if _, err := os.Stat(input); err == nil {
+	input := flag.Arg(0)
+	switch {
+	case srcMode:
+		// 输入是代码内容
+		return []byte(input)
+	case input == "-":
+		// 从标准输入读取代码
+		content, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			log.Fatalf("读取标准输入失败: %v\n", err)
+		}
+		return content
+	default:
 		// 输入是文件路径
-		content, err = os.ReadFile(input)
+		content, err := os.ReadFile(input)
 		if err != nil {
 			log.Fatalf("读取文件失败: %v\n", err)
 		}
-	} else {
-		content = []byte(input)
+		return content
 	}
-	return content
 }
 
 func getAstTree(content []byte) (*token.FileSet, *ast.File, error) {
@@ -11,12 +11,15 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
 )
 
 // MainEntry 表示一个main入口点
 type MainEntry struct {
-	Dir  string `json:"dir"`  // 相对于项目的目录
-	File string `json:"file"` // 文件名
+	Dir       string `json:"dir"`                 // 相对于项目的目录
+	File      string `json:"file"`                // 文件名
+	Generated bool   `json:"generated,omitempty"` // 是否是生成文件
 }
 
 // 命令行参数
@@ -24,12 +27,14 @@ var (
 	verbose    bool
 	outputFile string
 	help       bool
+	genMode    = generated.Mark
 )
 
 func init() {
 	flag.BoolVar(&verbose, "v", false, "启用详细输出模式")
 	flag.StringVar(&outputFile, "o", "", "输出文件路径（默认为标准输出）")
 	flag.BoolVar(&help, "h", false, "显示帮助信息")
+	flag.Var(&genMode, "generated", "生成文件的处理方式: mark 标记、skip 跳过、refuse 报错退出")
 	flag.Usage = usage
 }
 
@@ -40,6 +45,7 @@ func usage() {
 	fmt.Fprintf(os.Stderr, "\n示例:\n")
 	fmt.Fprintf(os.Stderr, "  %s /path/to/go/project\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "  %s -v -o result.json /path/to/go/project\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -generated skip /path/to/go/project\n", os.Args[0])
 }
 
 func main() {
@@ -154,6 +160,21 @@ func findMainEntries(projectPath string) ([]MainEntry, error) {
 		}
 
 		if isMainEntry {
+			isGenerated, err := generated.IsGeneratedFile(path)
+			if err != nil {
+				return err
+			}
+			skip, err := genMode.Check(path, isGenerated)
+			if err != nil {
+				return err
+			}
+			if skip {
+				if verbose {
+					fmt.Fprintf(os.Stderr, "跳过生成文件: %s\n", path)
+				}
+				return nil
+			}
+
 			if verbose {
 				fmt.Fprintf(os.Stderr, "找到main入口: %s\n", path)
 			}
@@ -172,8 +193,9 @@ func findMainEntries(projectPath string) ([]MainEntry, error) {
 
 			// 添加到结果
 			entries = append(entries, MainEntry{
-				Dir:  relDir,
-				File: info.Name(),
+				Dir:       relDir,
+				File:      info.Name(),
+				Generated: isGenerated,
 			})
 		}
 
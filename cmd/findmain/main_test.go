@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
 )
 
 func TestIsMainEntryFile(t *testing.T) {
@@ -162,3 +165,48 @@ func helper() {}`,
 		}
 	}
 }
+
+func TestFindMainEntriesGenerated(t *testing.T) {
+	tempDir := t.TempDir()
+	dirs := map[string]string{
+		"app": "package main\n\nfunc main() {}\n",
+		"gen": "// Code generated by stringer. DO NOT EDIT.\n\npackage main\n\nfunc main() {}\n",
+	}
+	for dir, content := range dirs {
+		if err := os.MkdirAll(filepath.Join(tempDir, dir), 0755); err != nil {
+			t.Fatalf("无法创建目录 %s: %v", dir, err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, dir, "main.go"), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件: %v", err)
+		}
+	}
+	defer func(mode generated.Mode) { genMode = mode }(genMode)
+
+	genMode = generated.Mark
+	entries, err := findMainEntries(tempDir)
+	if err != nil {
+		t.Fatalf("findMainEntries出错: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("mark: 找到 %d 个入口点, 期望 2", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Generated != (entry.Dir == "gen") {
+			t.Errorf("mark: 入口点 %s 的Generated为 %v", entry.Dir, entry.Generated)
+		}
+	}
+
+	genMode = generated.Skip
+	entries, err = findMainEntries(tempDir)
+	if err != nil {
+		t.Fatalf("findMainEntries出错: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Dir != "app" {
+		t.Errorf("skip: 得到 %+v, 期望只有 app", entries)
+	}
+
+	genMode = generated.Refuse
+	if _, err := findMainEntries(tempDir); !errors.Is(err, generated.ErrGenerated) {
+		t.Errorf("refuse: 期望 ErrGenerated 错误, 得到 %v", err)
+	}
+}
@@ -7,7 +7,7 @@ go build -o getcomments main.go
 # 检查构建结果
 if [ $? -eq 0 ]; then
     echo "构建成功，可执行文件: getcomments"
-    echo "用法: ./getcomments <文件路径或代码内容>"
+    echo "用法: ./getcomments [-src] <文件路径|目录|目录/...|->"
     
     # 移动到系统路径（可选）
     # read -p "是否要安装到系统路径？(y/n): " answer
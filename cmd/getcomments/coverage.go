@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runCoverage 执行 coverage 子命令，返回进程退出码
+func runCoverage(args []string) int {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	format := fs.String("format", "json", "输出格式: json 或 markdown")
+	minPercent := fs.Float64("min", 0, "模块的文档覆盖率低于该百分比时以退出码1结束")
+	var genMode generated.Mode
+	fs.Var(&genMode, "generated", "生成文件的处理方式: skip 跳过、mark 照常统计、refuse 报错退出（默认目录模式下跳过，显式指定的文件照常统计）")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s coverage [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "统计导出标识符的文档覆盖率，_test.go 文件不参与统计\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s coverage -format markdown ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s coverage -min 80 ./...\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	var report *getcomments.CoverageReport
+	input := fs.Arg(0)
+	mode := generatedModeFor(genMode, input)
+	extractor := getcomments.NewExtractor(getcomments.WithGenerated(mode))
+	if getcomments.IsDirPattern(input) {
+		var err error
+		if report, err = extractor.DocCoverageDir(input); err != nil {
+			fmt.Fprintf(os.Stderr, "统计文档覆盖率失败: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range report.Errors {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+		}
+		reportSkipped(report.Skipped)
+	} else {
+		fc, err := extractor.DocCoverage(getcomments.FromFile(input))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "统计文档覆盖率失败: %v\n", err)
+			return 1
+		}
+		var files []getcomments.FileCoverage
+		if !skippedGeneratedFile(mode, input) {
+			files = append(files, *fc)
+		}
+		report = getcomments.NewCoverageReport(files)
+	}
+
+	var err error
+	switch *format {
+	case "json":
+		var output []byte
+		if output, err = json.MarshalIndent(report, "", "    "); err == nil {
+			fmt.Println(string(output))
+		}
+	case "markdown":
+		writeCoverageMarkdown(os.Stdout, report)
+	default:
+		err = fmt.Errorf("不支持的输出格式 %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	if report.Percent < *minPercent {
+		fmt.Fprintf(os.Stderr, "文档覆盖率为 %.1f%%，低于要求的 %.1f%%\n", report.Percent, *minPercent)
+		return 1
+	}
+	return 0
+}
+
+func writeCoverageMarkdown(w io.Writer, report *getcomments.CoverageReport) {
+	fmt.Fprintf(w, "# 文档覆盖率: %.1f%% (%d/%d)\n\n", report.Percent, report.Documented, report.Total)
+
+	fmt.Fprintf(w, "| 包 | 文件 | 覆盖率 | 已覆盖/总数 |\n")
+	fmt.Fprintf(w, "|----|------|--------|-------------|\n")
+	for _, pkg := range report.Packages {
+		fmt.Fprintf(w, "| %s | | %.1f%% | %d/%d |\n", pkg.Package, pkg.Percent, pkg.Documented, pkg.Total)
+		for _, fc := range pkg.Files {
+			fmt.Fprintf(w, "| | %s | %.1f%% | %d/%d |\n", fc.File, fc.Percent, fc.Documented, fc.Total)
+		}
+	}
+
+	gaps := report.Gaps()
+	if len(gaps) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n## 缺少文档 (%d)\n\n", len(gaps))
+	fmt.Fprintf(w, "| 位置 | 类型 | 名称 | 问题 | 现有文档 |\n")
+	fmt.Fprintf(w, "|------|------|------|------|----------|\n")
+	for _, item := range gaps {
+		problem := "缺少文档"
+		if item.Status == getcomments.DocBadPrefix {
+			problem = "没有以名称开头"
+		}
+		fmt.Fprintf(w, "| %s:%d | %s | %s | %s | %s |\n",
+			item.File, item.Pos.Line, item.Kind, item.Name, problem, strings.ReplaceAll(item.Doc, "|", `\|`))
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runDeadCode 执行 deadcode 子命令，返回进程退出码
+func runDeadCode(args []string) int {
+	fs := flag.NewFlagSet("deadcode", flag.ExitOnError)
+	format := fs.String("format", "text", "输出格式: text 或 json")
+	minConfidence := fs.Float64("min-confidence", 0.5, "只报告与删除置信度不低于该值的代码（0到1）")
+	fix := fs.Bool("fix", false, "删除找到的注释代码并写回原文件")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s deadcode [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "查找被注释掉的代码，未指定 -fix 且找到代码时以退出码1结束\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s deadcode ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s deadcode -min-confidence 0.8 -fix main.go\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 %q\n", *format)
+		return 1
+	}
+
+	input := fs.Arg(0)
+	inputs := []getcomments.Input{getcomments.FromFile(input)}
+	if getcomments.IsDirPattern(input) {
+		dir, err := getcomments.ListDir(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range dir.Errors {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+		}
+		inputs = dir.Inputs
+	}
+
+	code := 0
+	found := []deadCodeItem{}
+	for _, in := range inputs {
+		blocks, err := getcomments.FindDeadCode(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", in.Filename(), err)
+			code = 1
+			continue
+		}
+		n := len(found)
+		for _, block := range blocks {
+			if block.Confidence >= *minConfidence {
+				found = append(found, deadCodeItem{DeadCode: block})
+			}
+		}
+		if !*fix || len(found) == n {
+			continue
+		}
+		result, err := getcomments.RemoveDeadCode(in, *minConfidence)
+		if err == nil {
+			err = writeFilePreservingMode(in.Path(), result.Source)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", in.Filename(), err)
+			code = 1
+			continue
+		}
+		// 只有写回成功的文件中的代码才算已删除
+		for i := n; i < len(found); i++ {
+			found[i].Deleted = true
+		}
+	}
+
+	switch *format {
+	case "json":
+		output, err := json.MarshalIndent(found, "", "    ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		fmt.Println(string(output))
+	default:
+		for _, block := range found {
+			action := "注释代码"
+			if block.Deleted {
+				action = "已删除注释代码"
+			}
+			first, _, _ := strings.Cut(block.Code, "\n")
+			fmt.Printf("%s:%d:%d: %s %d 行 (%s, 置信度 %.2f): %s\n",
+				block.File, block.Start.Line, block.Start.Column, action, block.Lines, block.Kind, block.Confidence, first)
+		}
+	}
+
+	if !*fix && len(found) > 0 {
+		return 1
+	}
+	return code
+}
+
+// deadCodeItem 一段注释代码，以及指定 -fix 时是否已从文件中删除
+type deadCodeItem struct {
+	getcomments.DeadCode
+	Deleted bool `json:"deleted,omitempty"`
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// changeLabels 文本格式中每种变更的说明
+var changeLabels = map[getcomments.ChangeKind]string{
+	getcomments.ChangeAdded:   "新增",
+	getcomments.ChangeRemoved: "删除",
+	getcomments.ChangeEdited:  "修改",
+	getcomments.ChangeMoved:   "移动",
+	getcomments.ChangeStale:   "代码已修改，注释未变",
+}
+
+// runDiff 执行 diff 子命令，返回进程退出码
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "输出格式: text 或 json")
+	failOn := fs.String("fail-on", "", "出现这些类型的变更时以退出码1结束，如 stale 或 stale,removed")
+	var genMode generated.Mode
+	fs.Var(&genMode, "generated", "生成文件的处理方式: skip 跳过、mark 照常比较、refuse 报错退出（默认比较目录时跳过，显式指定的文件照常比较）")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s diff [选项] <旧文件|旧目录|旧目录/...> <新文件|新目录|新目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "按所属符号而不是行号比较两个版本的注释，报告新增(added)、删除(removed)、修改(edited)、\n")
+		fmt.Fprintf(os.Stderr, "移到其他符号下(moved)的注释，以及代码已修改但注释未变(stale)的符号\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s diff old/main.go main.go\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s diff -fail-on stale ../base/... ./...\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 %q\n", *format)
+		return 1
+	}
+	failKinds, err := parseChangeKinds(*failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	extractor := getcomments.NewExtractor(getcomments.WithGenerated(generatedModeFor(genMode, fs.Arg(0))))
+	diff, err := extractor.DiffComments(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "比较注释失败: %v\n", err)
+		return 1
+	}
+	for _, fileErr := range diff.Errors {
+		fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+	}
+	reportSkipped(diff.Skipped)
+
+	if *format == "json" {
+		var output []byte
+		if output, err = json.MarshalIndent(diff, "", "    "); err == nil {
+			fmt.Println(string(output))
+		}
+	} else {
+		err = writeDiffText(os.Stdout, diff)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	code := 0
+	for _, kind := range failKinds {
+		if n := diff.Count(kind); n > 0 {
+			fmt.Fprintf(os.Stderr, "存在 %d 个 %s 变更\n", n, kind)
+			code = 1
+		}
+	}
+	return code
+}
+
+// parseChangeKinds 解析 -fail-on 参数，如 "stale,removed"
+func parseChangeKinds(s string) ([]getcomments.ChangeKind, error) {
+	var kinds []getcomments.ChangeKind
+	if s == "" {
+		return kinds, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		kind := getcomments.ChangeKind(strings.ToLower(strings.TrimSpace(item)))
+		if _, ok := changeLabels[kind]; !ok {
+			names := make([]string, len(getcomments.ChangeKinds))
+			for i, k := range getcomments.ChangeKinds {
+				names[i] = string(k)
+			}
+			return nil, fmt.Errorf("无效的 -fail-on 变更类型 %q，可选值为 %s", item, strings.Join(names, "、"))
+		}
+		kinds = append(kinds, kind)
+	}
+	return kinds, nil
+}
+
+// writeDiffText 以类似统一diff的格式输出变更：每个变更以 "@@ 类型 符号 位置 @@" 开头，
+// 随后是注释的每一行，删除的行以 "-" 开头，新增的行以 "+" 开头，未变的行以空格开头；
+// 同一符号下连续的同类变更（如多行文档注释）合并在一个标题下
+func writeDiffText(w io.Writer, diff *getcomments.CommentDiff) error {
+	if len(diff.Changes) == 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", diff.Old, diff.New)
+	last := ""
+	for _, c := range diff.Changes {
+		header := changeLabels[c.Kind] + " " + c.Symbol
+		if c.Kind == getcomments.ChangeMoved {
+			header += " (原符号 " + c.OldSymbol + ")"
+		}
+		if header == last {
+			writeDiffChange(w, c)
+			continue
+		}
+		last = header
+		var locations []string
+		if c.Old != nil {
+			locations = append(locations, fmt.Sprintf("%s:%d", c.Old.File, c.Old.Start.Line))
+		}
+		if c.New != nil {
+			locations = append(locations, fmt.Sprintf("%s:%d", c.New.File, c.New.Start.Line))
+		}
+		fmt.Fprintf(w, "@@ %s %s @@\n", header, strings.Join(locations, " -> "))
+		writeDiffChange(w, c)
+	}
+	return nil
+}
+
+// writeDiffChange 输出一个变更中注释的每一行
+func writeDiffChange(w io.Writer, c getcomments.CommentChange) {
+	switch c.Kind {
+	case getcomments.ChangeAdded:
+		writeDiffLines(w, "+", c.New.Raw)
+	case getcomments.ChangeRemoved:
+		writeDiffLines(w, "-", c.Old.Raw)
+	case getcomments.ChangeEdited:
+		writeDiffLines(w, "-", c.Old.Raw)
+		writeDiffLines(w, "+", c.New.Raw)
+	default:
+		writeDiffLines(w, " ", c.New.Raw)
+	}
+}
+
+func writeDiffLines(w io.Writer, prefix, raw string) {
+	for _, line := range strings.Split(raw, "\n") {
+		fmt.Fprintf(w, "%s%s\n", prefix, line)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runExamples 执行 examples 子命令，返回进程退出码
+func runExamples(args []string) int {
+	fs := flag.NewFlagSet("examples", flag.ExitOnError)
+	format := fs.String("format", "text", "输出格式: text、json 或 markdown")
+	outputFile := fs.String("o", "", "输出文件路径（默认为标准输出），供文档构建读取")
+	requireOutput := fs.Bool("require-output", false, "存在没有输出注释的示例时以退出码1结束")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s examples [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "检查 _test.go 文件中的示例函数：输出注释必须是函数体中的最后一条注释，\n")
+		fmt.Fprintf(os.Stderr, "名称必须对应包中存在的标识符，并列出没有输出注释的示例；存在问题时以退出码1结束\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s examples ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s examples -format json -o examples.json ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s examples -require-output example_test.go\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" && *format != "markdown" {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 %q\n", *format)
+		return 1
+	}
+
+	var report *getcomments.ExampleReport
+	var err error
+	input := fs.Arg(0)
+	if getcomments.IsDirPattern(input) {
+		report, err = getcomments.FindExamplesDir(input)
+	} else {
+		report, err = getcomments.FindExamples(getcomments.FromFile(input))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "检查示例函数失败: %v\n", err)
+		return 1
+	}
+	for _, fileErr := range report.Errors {
+		fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+	}
+
+	var out io.Writer = os.Stdout
+	if *outputFile != "" {
+		file, err := os.Create(*outputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无法创建输出文件: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		out = file
+	}
+	switch *format {
+	case "json":
+		var output []byte
+		if output, err = json.MarshalIndent(report, "", "    "); err == nil {
+			fmt.Fprintln(out, string(output))
+		}
+	case "markdown":
+		writeExamplesMarkdown(out, report)
+	default:
+		writeExamplesText(out, report)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	code := 0
+	if len(report.Diagnostics) > 0 {
+		code = 1
+	}
+	if *requireOutput && len(report.Missing) > 0 {
+		fmt.Fprintf(os.Stderr, "存在 %d 个没有输出注释的示例\n", len(report.Missing))
+		code = 1
+	}
+	return code
+}
+
+func writeExamplesText(w io.Writer, report *getcomments.ExampleReport) {
+	for _, d := range report.Diagnostics {
+		fmt.Fprintln(w, d.String())
+	}
+	for _, name := range report.Missing {
+		fmt.Fprintf(w, "没有输出注释: %s\n", name)
+	}
+}
+
+// writeExamplesMarkdown 输出示例列表，以及需要处理的问题
+func writeExamplesMarkdown(w io.Writer, report *getcomments.ExampleReport) {
+	escape := strings.NewReplacer("|", `\|`, "\n", "<br>")
+	runnable := 0
+	for _, ex := range report.Examples {
+		if ex.Runnable {
+			runnable++
+		}
+	}
+	fmt.Fprintf(w, "# 示例函数: %d 个，%d 个会检查输出，%d 个没有输出注释\n\n", len(report.Examples), runnable, len(report.Missing))
+
+	if len(report.Examples) > 0 {
+		fmt.Fprintf(w, "| 位置 | 示例 | 说明的标识符 | 后缀 | 输出 |\n")
+		fmt.Fprintf(w, "|------|------|--------------|------|------|\n")
+		for _, ex := range report.Examples {
+			output := "无"
+			if ex.Output != nil {
+				output = escape.Replace(strings.TrimSuffix(ex.Output.Text, "\n"))
+				if ex.Output.Unordered {
+					output = "（无序）" + output
+				}
+				if !ex.Output.Final {
+					output += "（不检查）"
+				}
+			}
+			fmt.Fprintf(w, "| %s:%d | %s | %s | %s | %s |\n",
+				ex.File, ex.Pos.Line, ex.Name, ex.Target, ex.Suffix, output)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(report.Diagnostics) > 0 {
+		fmt.Fprintf(w, "## 问题\n\n")
+		for _, d := range report.Diagnostics {
+			fmt.Fprintf(w, "- %s\n", escape.Replace(d.String()))
+		}
+	}
+}
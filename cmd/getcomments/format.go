@@ -0,0 +1,350 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// outputFormats 支持的输出格式
+var outputFormats = []string{"json", "jsonl", "csv", "yaml", "markdown", "annotated"}
+
+func isOutputFormat(format string) bool {
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceLoader 按结果中的文件名读取源代码，annotated 格式使用
+type sourceLoader func(file string) ([]byte, error)
+
+// writeOutput 按格式输出注释，records 应已按位置排序
+// json 与 yaml 输出 doc（分组、扁平或按符号的映射），其余格式逐条输出记录
+func writeOutput(w io.Writer, format string, records getcomments.CommentRecords, doc any, load sourceLoader) error {
+	switch format {
+	case "json":
+		output, err := json.MarshalIndent(doc, "", "    ")
+		if err != nil {
+			return fmt.Errorf("序列化结果失败: %v", err)
+		}
+		_, err = fmt.Fprintln(w, string(output))
+		return err
+	case "yaml":
+		return writeYAML(w, doc)
+	case "jsonl":
+		return writeJSONLines(w, records)
+	case "csv":
+		return writeRecordsCSV(w, records)
+	case "markdown":
+		return writeRecordsMarkdown(w, records)
+	case "annotated":
+		return writeAnnotated(w, records, load)
+	}
+	return fmt.Errorf("不支持的输出格式 %q", format)
+}
+
+// writeJSONLines 每行输出一条注释记录
+func writeJSONLines(w io.Writer, records getcomments.CommentRecords) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return fmt.Errorf("序列化结果失败: %v", err)
+		}
+	}
+	return nil
+}
+
+func writeRecordsCSV(w io.Writer, records getcomments.CommentRecords) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"file", "line", "start_line", "start_column", "end_line", "end_column",
+		"kind", "block", "node_kind", "node_name", "symbol", "owner", "path", "generated", "text", "raw",
+	})
+	for _, r := range records {
+		cw.Write([]string{
+			r.File, strconv.Itoa(r.Line),
+			strconv.Itoa(r.Start.Line), strconv.Itoa(r.Start.Column),
+			strconv.Itoa(r.End.Line), strconv.Itoa(r.End.Column),
+			string(r.Kind), strconv.FormatBool(r.Block), r.Node.Kind, r.Node.Name, r.Symbol, string(r.Owner), r.Path,
+			strconv.FormatBool(r.Generated), r.Text, r.Raw,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeRecordsMarkdown 每个文件输出一个表格
+func writeRecordsMarkdown(w io.Writer, records getcomments.CommentRecords) error {
+	escape := strings.NewReplacer("|", `\|`, "\n", "<br>")
+	for i, r := range records {
+		if i == 0 || records[i-1].File != r.File {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "## %s\n\n", r.File)
+			fmt.Fprintf(w, "| 行 | 位置 | 类型 | 节点 | 符号 | 注释 |\n")
+			fmt.Fprintf(w, "|----|------|------|------|------|------|\n")
+		}
+		fmt.Fprintf(w, "| %d | %d:%d | %s | %s | %s | %s |\n",
+			r.Line, r.Start.Line, r.Start.Column, r.Kind, r.Node, r.Symbol, escape.Replace(r.Text))
+	}
+	return nil
+}
+
+// writeAnnotated 输出带注释的源代码：注释从代码中移到它所关联的代码行右侧，
+// 只有注释的行不再单独输出；没有关联到代码的注释保留在原处
+func writeAnnotated(w io.Writer, records getcomments.CommentRecords, load sourceLoader) error {
+	for start := 0; start < len(records); {
+		end := start
+		for end < len(records) && records[end].File == records[start].File {
+			end++
+		}
+		src, err := load(records[start].File)
+		if err != nil {
+			return fmt.Errorf("读取源代码失败: %v", err)
+		}
+		if start > 0 {
+			fmt.Fprintln(w)
+		}
+		writeAnnotatedFile(w, records[start].File, string(src), records[start:end])
+		start = end
+	}
+	return nil
+}
+
+// annotatedRow annotated 格式中的一行
+type annotatedRow struct {
+	line  int
+	code  string
+	notes []string
+}
+
+// annotatedWidth 对齐注释时代码列的最大宽度，更长的代码行后直接跟注释
+const annotatedWidth = 64
+
+func writeAnnotatedFile(w io.Writer, file, src string, records getcomments.CommentRecords) {
+	lines := strings.Split(src, "\n")
+	// 每行中需要移除的注释范围（字节偏移，左闭右开）以及显示在该行右侧的注释
+	cuts := make(map[int][][2]int)
+	notes := make(map[int][]string)
+	for _, r := range records {
+		for i, text := range strings.Split(r.Text, "\n") {
+			if i == 0 {
+				text = "[" + string(r.Kind) + "] " + text
+			}
+			notes[r.Line] = append(notes[r.Line], text)
+		}
+		for line := r.Start.Line; line <= r.End.Line && line <= len(lines); line++ {
+			from, to := 0, len(lines[line-1])
+			if line == r.Start.Line {
+				from = r.Start.Column - 1
+			}
+			if line == r.End.Line {
+				to = r.End.Column - 1
+			}
+			cuts[line] = append(cuts[line], [2]int{from, to})
+		}
+	}
+
+	var rows []annotatedRow
+	width := 0
+	for i, text := range lines {
+		line := i + 1
+		code := strings.TrimRight(strings.ReplaceAll(removeRanges(text, cuts[line]), "\t", "    "), " ")
+		if code == "" && len(cuts[line]) > 0 && len(notes[line]) == 0 {
+			continue
+		}
+		if i == len(lines)-1 && code == "" && len(notes[line]) == 0 {
+			continue
+		}
+		rows = append(rows, annotatedRow{line: line, code: code, notes: notes[line]})
+		if len(notes[line]) > 0 {
+			width = max(width, min(displayWidth(code), annotatedWidth))
+		}
+	}
+
+	fmt.Fprintf(w, "=== %s ===\n", file)
+	for _, row := range rows {
+		if len(row.notes) == 0 {
+			fmt.Fprintf(w, "%5d  %s\n", row.line, row.code)
+			continue
+		}
+		pad := strings.Repeat(" ", max(width-displayWidth(row.code), 0))
+		fmt.Fprintf(w, "%5d  %s%s  │ %s\n", row.line, row.code, pad, row.notes[0])
+		for _, note := range row.notes[1:] {
+			fmt.Fprintf(w, "%5s  %s  │ %s\n", "", strings.Repeat(" ", width), note)
+		}
+	}
+}
+
+// removeRanges 移除一行中的若干字节范围
+func removeRanges(text string, ranges [][2]int) string {
+	if len(ranges) == 0 {
+		return text
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var b strings.Builder
+	pos := 0
+	for _, r := range ranges {
+		from, to := max(r[0], pos), min(r[1], len(text))
+		if from >= to {
+			continue
+		}
+		b.WriteString(text[pos:from])
+		pos = to
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// displayWidth 返回文本在终端中的显示宽度，中日韩文字与全角符号占两列
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+			(r >= 0xFF00 && r <= 0xFF60) || (r >= 0x3000 && r <= 0x303F) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// writeYAML 以YAML格式输出v：先序列化为JSON再逐个记号转换，因此键的顺序与JSON输出一致
+func writeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("序列化结果失败: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	node, err := readYAMLNode(dec)
+	if err != nil {
+		return fmt.Errorf("序列化结果失败: %v", err)
+	}
+	var buf bytes.Buffer
+	if s, ok := node.inline(); ok {
+		buf.WriteString(s + "\n")
+	} else {
+		node.write(&buf, 0)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// yamlNode JSON值对应的YAML节点
+type yamlNode struct {
+	scalar string      // 标量的YAML表示
+	object bool        // 是否是对象
+	array  bool        // 是否是数组
+	keys   []string    // 对象的键，保持JSON中的顺序
+	items  []*yamlNode // 对象的值或数组的元素
+}
+
+func (n *yamlNode) isScalar() bool {
+	return !n.object && !n.array
+}
+
+func readYAMLNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &yamlNode{object: t == '{', array: t == '['}
+		for dec.More() {
+			if n.object {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				n.keys = append(n.keys, key.(string))
+			}
+			item, err := readYAMLNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, item)
+		}
+		// 读取结束的 } 或 ]
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		return &yamlNode{scalar: strconv.Quote(t)}, nil
+	case json.Number:
+		return &yamlNode{scalar: t.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(t)}, nil
+	}
+	return &yamlNode{scalar: "null"}, nil
+}
+
+// inline 返回标量或空容器在同一行中的表示，其他节点返回false
+func (n *yamlNode) inline() (string, bool) {
+	switch {
+	case n.isScalar():
+		return n.scalar, true
+	case len(n.items) > 0:
+		return "", false
+	case n.object:
+		return "{}", true
+	}
+	return "[]", true
+}
+
+// write 输出对象或数组的每一项，indent 为缩进的空格数
+func (n *yamlNode) write(buf *bytes.Buffer, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for i, item := range n.items {
+		if n.object {
+			buf.WriteString(pad + yamlKey(n.keys[i]) + ":")
+			if s, ok := item.inline(); ok {
+				buf.WriteString(" " + s + "\n")
+				continue
+			}
+			buf.WriteString("\n")
+			item.write(buf, indent+2)
+			continue
+		}
+		if s, ok := item.inline(); ok {
+			buf.WriteString(pad + "- " + s + "\n")
+			continue
+		}
+		// 元素按 indent+2 缩进输出，再把第一行的缩进换成 "- "
+		var sub bytes.Buffer
+		item.write(&sub, indent+2)
+		buf.WriteString(pad + "- ")
+		buf.Write(sub.Bytes()[indent+2:])
+	}
+}
+
+var plainYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// yamlKey 返回YAML中的键，含有特殊字符或可能被解析为布尔值、空值（如 yes、null，包名可能是这些词）时加引号
+func yamlKey(key string) string {
+	switch strings.ToLower(key) {
+	case "y", "n", "yes", "no", "on", "off", "true", "false", "null":
+		return strconv.Quote(key)
+	}
+	if plainYAMLKey.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runLint 执行 lint 子命令，返回进程退出码
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := fs.String("format", "text", "输出格式: text 或 json")
+	fix := fs.Bool("fix", false, "自动修复可修复的问题并写回原文件")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s lint [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "检查文档注释是否符合Go的约定，存在问题时以退出码1结束\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s lint ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s lint -fix main.go\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 %q\n", *format)
+		return 1
+	}
+
+	input := fs.Arg(0)
+	inputs := []getcomments.Input{getcomments.FromFile(input)}
+	if getcomments.IsDirPattern(input) {
+		dir, err := getcomments.ListDir(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range dir.Errors {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+		}
+		inputs = dir.Inputs
+	}
+
+	code := 0
+	var diagnostics []getcomments.Diagnostic
+	for _, in := range inputs {
+		result, err := getcomments.LintDocs(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", in.Filename(), err)
+			code = 1
+			continue
+		}
+		remaining := result.Diagnostics
+		if *fix && result.Fixed > 0 {
+			if err := writeFilePreservingMode(in.Path(), result.Source); err != nil {
+				fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", in.Filename(), err)
+				code = 1
+				continue
+			}
+			// 修复后只报告无法自动修复的问题
+			remaining = nil
+			for _, d := range result.Diagnostics {
+				if d.Suggestion == "" {
+					remaining = append(remaining, d)
+				}
+			}
+		}
+		diagnostics = append(diagnostics, remaining...)
+	}
+
+	if err := writeDiagnostics(*format, diagnostics); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	if len(diagnostics) > 0 {
+		return 1
+	}
+	return code
+}
+
+// writeDiagnostics 按格式输出诊断信息，text 格式在可修复的诊断下方输出修复建议
+func writeDiagnostics(format string, diagnostics []getcomments.Diagnostic) error {
+	if format == "json" {
+		if diagnostics == nil {
+			diagnostics = []getcomments.Diagnostic{}
+		}
+		output, err := json.MarshalIndent(diagnostics, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+	for _, d := range diagnostics {
+		fmt.Println(d.String())
+		if d.Suggestion != "" {
+			fmt.Printf("\t建议: %s\n", d.Suggestion)
+		}
+	}
+	return nil
+}
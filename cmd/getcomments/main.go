@@ -1,36 +1,309 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
 
+	"github.com/monshunter/ast-practice/pkg/generated"
 	"github.com/monshunter/ast-practice/pkg/getcomments"
 )
 
+// 命令行参数
+var (
+	srcMode  bool
+	filename string
+	flat     bool
+	bySymbol bool
+	partial  bool
+	jobs     int
+	genMode  generated.Mode // 未指定时目录模式跳过生成文件，显式指定的文件照常提取
+
+	// 输出选项
+	format     string
+	outputFile string
+
+	// 过滤选项
+	docOnly           bool
+	trailingOnly      bool
+	inlineOnly        bool
+	excludeDirectives bool
+	includePattern    string
+	excludePattern    string
+	minLength         int
+)
+
+func init() {
+	flag.BoolVar(&srcMode, "src", false, "将参数视为Go源代码而不是文件路径")
+	flag.StringVar(&filename, "name", "", "源代码或标准输入使用的文件名（默认分别为 code.go、stdin.go）")
+	flag.BoolVar(&bySymbol, "by-symbol", false, "按限定符号（如 main.MainEntry.Dir、main.(*T).run#stmt3）而不是行号输出注释，代码移动后键保持不变")
+	flag.StringVar(&format, "format", "json", "输出格式: "+strings.Join(outputFormats, "、")+"，均按文件与行号排列")
+	flag.StringVar(&outputFile, "o", "", "输出文件路径（默认为标准输出）")
+	flag.BoolVar(&flat, "flat", false, "输出旧的扁平格式（\"文件名:行号\": [注释...]），不区分文档、上方与行尾注释")
+	flag.IntVar(&jobs, "j", 0, "目录模式下并发提取的文件数，0表示使用全部CPU")
+	flag.BoolVar(&partial, "partial", false, "容错模式：代码有语法错误时输出能够关联的注释，并在 errors 字段中列出语法错误，不以错误退出")
+	flag.Var(&genMode, "generated", "生成文件的处理方式: skip 跳过、mark 提取并标记、refuse 报错退出（默认目录模式下跳过，显式指定的文件照常提取）")
+	flag.BoolVar(&docOnly, "doc-only", false, "只保留文档注释")
+	flag.BoolVar(&trailingOnly, "trailing-only", false, "只保留行尾注释")
+	flag.BoolVar(&inlineOnly, "inline-only", false, "只保留函数体内部的注释")
+	flag.BoolVar(&excludeDirectives, "no-directives", false, "排除 //go:generate、//nolint 等编译器与检查工具指令")
+	flag.StringVar(&includePattern, "include", "", "只保留文本匹配该正则表达式的注释")
+	flag.StringVar(&excludePattern, "exclude", "", "排除文本匹配该正则表达式的注释")
+	flag.IntVar(&minLength, "min-length", 0, "注释文本的最少字符数")
+	flag.Usage = usage
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "用法: %s [选项] <文件路径|目录|目录/...|->\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s todos [选项] <文件路径|目录|目录/...|->\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s translate [选项] <文件路径|目录|目录/...|->\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s coverage [选项] <文件路径|目录|目录/...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s lint [选项] <文件路径|目录|目录/...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s stale [选项] <文件路径|目录|目录/...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s deadcode [选项] <文件路径|目录|目录/...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s stats [选项] <文件路径|目录|目录/...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s diff [选项] <旧文件|旧目录/...> <新文件|新目录/...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "      %s examples [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "选项:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\n示例:\n")
+	fmt.Fprintf(os.Stderr, "  %s main.go\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  cat main.go | %s -name main.go -\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -src 'package main // 注释'\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -by-symbol ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -format annotated main.go\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -format csv -o comments.csv ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -generated mark ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -partial editing.go\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s -doc-only -no-directives -exclude '(?i)copyright' ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s todos -group-by owner -format markdown ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s translate -glossary glossary.json -o ../en ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s coverage -min 80 ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s lint -fix ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s stale ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s deadcode -fix ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s stats -format markdown -top 10 ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s diff -fail-on stale ../base/... ./...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "  %s examples -format json -o examples.json ./...\n", os.Args[0])
+}
+
 func main() {
+	// 子命令
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "todos":
+			os.Exit(runTodos(os.Args[2:]))
+		case "translate":
+			os.Exit(runTranslate(os.Args[2:]))
+		case "coverage":
+			os.Exit(runCoverage(os.Args[2:]))
+		case "lint":
+			os.Exit(runLint(os.Args[2:]))
+		case "stale":
+			os.Exit(runStale(os.Args[2:]))
+		case "deadcode":
+			os.Exit(runDeadCode(os.Args[2:]))
+		case "stats":
+			os.Exit(runStats(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "examples":
+			os.Exit(runExamples(os.Args[2:]))
+		}
+	}
+
+	flag.Parse()
+
 	// 检查参数
-	if len(os.Args) != 2 {
-		fmt.Println("用法: getcomments <文件路径或代码内容>")
+	args := flag.Args()
+	if len(args) != 1 {
+		usage()
+		os.Exit(1)
+	}
+
+	input := args[0]
+	if !isOutputFormat(format) {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 %q，可选值为 %s\n", format, strings.Join(outputFormats, "、"))
 		os.Exit(1)
 	}
 
-	input := os.Args[1]
+	filter, err := buildFilter()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		os.Exit(1)
+	}
+	mode := genMode
+	if !srcMode {
+		mode = generatedModeFor(genMode, input)
+	}
+	opts := []getcomments.Option{getcomments.WithFilter(filter), getcomments.WithGenerated(mode)}
+	if partial {
+		opts = append(opts, getcomments.WithPartial())
+	}
+	extractor := getcomments.NewExtractor(opts...)
 
 	// 提取注释
 	// commentsMap, err := ExtractComments(input)
-	commentsMap, err := getcomments.ExtractComments(input)
+	var records getcomments.CommentRecords
+	var syntaxErrs getcomments.SyntaxErrors
+	// load 读取结果中的文件，annotated 格式使用；代码与标准输入直接使用已读取的内容
+	var load sourceLoader
+	switch {
+	case srcMode:
+		load = sourceOf(nameOr("code.go"), []byte(input))
+		records, err = extractor.Extract(getcomments.FromSource(nameOr("code.go"), []byte(input)))
+	case input == "-":
+		var src []byte
+		if src, err = io.ReadAll(os.Stdin); err == nil {
+			load = sourceOf(nameOr("stdin.go"), src)
+			records, err = extractor.Extract(getcomments.FromSource(nameOr("stdin.go"), src))
+		}
+	case getcomments.IsDirPattern(input):
+		var result *getcomments.DirResult
+		if result, err = extractDir(extractor, input); err == nil {
+			records, syntaxErrs = result.Records, result.SyntaxErrors
+			load = func(file string) ([]byte, error) {
+				return os.ReadFile(filepath.Join(result.Root, filepath.FromSlash(file)))
+			}
+		}
+	default:
+		// 结果中的文件名只有文件的基本名称
+		load = func(string) ([]byte, error) { return os.ReadFile(input) }
+		if records, err = extractor.Extract(getcomments.FromFile(input)); err == nil {
+			skippedGeneratedFile(mode, input)
+		}
+	}
+	if errs, ok := getcomments.AsSyntaxErrors(err); ok {
+		// 容错模式下保留部分结果
+		syntaxErrs, err = errs, nil
+	}
 	if err != nil {
 		fmt.Printf("提取注释失败: %v\n", err)
 		os.Exit(1)
 	}
+	// 所有格式都按文件与位置输出
+	records.Sort()
 
-	// 输出JSON格式结果
-	output, err := json.MarshalIndent(commentsMap, "", "    ")
-	if err != nil {
-		fmt.Printf("序列化结果失败: %v\n", err)
+	// json 与 yaml 默认输出按注释类型分组的映射
+	grouped := records.GroupedOutput()
+	grouped.Errors = syntaxErrs
+	if bySymbol {
+		grouped.Comments = records.SymbolMap()
+	}
+	var doc any = grouped
+	if flat {
+		doc = records.CommentsMap()
+	}
+	if flat || (format != "json" && format != "yaml") {
+		// 其他格式没有 errors 字段，语法错误输出到标准错误
+		for _, e := range syntaxErrs {
+			fmt.Fprintf(os.Stderr, "警告: %v\n", e)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if outputFile != "" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: 无法创建输出文件: %v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+	if err := writeOutput(out, format, records, doc, load); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
 		os.Exit(1)
 	}
+}
+
+// sourceOf 返回只能读取指定文件的sourceLoader
+func sourceOf(name string, src []byte) sourceLoader {
+	return func(file string) ([]byte, error) {
+		if file != name {
+			return nil, fmt.Errorf("没有文件 %s 的源代码", file)
+		}
+		return src, nil
+	}
+}
+
+// nameOr 返回 -name 指定的文件名，未指定时返回默认值
+func nameOr(defaultName string) string {
+	if filename != "" {
+		return filename
+	}
+	return defaultName
+}
+
+// buildFilter 根据命令行参数构造过滤选项
+func buildFilter() (getcomments.Filter, error) {
+	filter := getcomments.Filter{
+		DocOnly:           docOnly,
+		TrailingOnly:      trailingOnly,
+		InlineOnly:        inlineOnly,
+		ExcludeDirectives: excludeDirectives,
+		MinLength:         minLength,
+	}
+	var err error
+	if includePattern != "" {
+		if filter.Include, err = regexp.Compile(includePattern); err != nil {
+			return filter, fmt.Errorf("无效的 -include 正则表达式: %v", err)
+		}
+	}
+	if excludePattern != "" {
+		if filter.Exclude, err = regexp.Compile(excludePattern); err != nil {
+			return filter, fmt.Errorf("无效的 -exclude 正则表达式: %v", err)
+		}
+	}
+	return filter, nil
+}
+
+// extractDir 以目录模式并发提取注释，单个文件的错误作为警告输出到标准错误
+// 收到中断信号时停止提取
+func extractDir(extractor *getcomments.Extractor, pattern string) (*getcomments.DirResult, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := extractor.ExtractDirParallel(ctx, pattern, getcomments.BatchOptions{Workers: jobs})
+	if err != nil {
+		return nil, err
+	}
+	for _, fileErr := range result.Errors {
+		fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+	}
+	reportSkipped(result.Skipped)
+	return result, nil
+}
 
-	fmt.Println(string(output))
+// generatedModeFor 返回生成文件的处理方式：未指定时目录模式跳过生成文件，显式指定的文件照常处理
+func generatedModeFor(mode generated.Mode, input string) generated.Mode {
+	if mode == "" && getcomments.IsDirPattern(input) {
+		return generated.Skip
+	}
+	return mode
+}
+
+// skippedGeneratedFile 判断显式指定的文件是否是按skip方式跳过的生成文件，跳过时输出说明
+func skippedGeneratedFile(mode generated.Mode, path string) bool {
+	if mode != generated.Skip {
+		return false
+	}
+	isGenerated, _ := generated.IsGeneratedFile(path)
+	if isGenerated {
+		fmt.Fprintf(os.Stderr, "跳过生成文件 %s\n", path)
+	}
+	return isGenerated
+}
+
+// reportSkipped 输出目录模式下跳过的生成文件
+func reportSkipped(files []string) {
+	for _, file := range files {
+		fmt.Fprintf(os.Stderr, "跳过生成文件 %s\n", file)
+	}
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runStale 执行 stale 子命令，返回进程退出码
+func runStale(args []string) int {
+	fs := flag.NewFlagSet("stale", flag.ExitOnError)
+	format := fs.String("format", "text", "输出格式: text 或 json")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s stale [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "检查文档注释中引用的参数、字段、函数等名称是否仍然存在，存在过时的引用时以退出码1结束\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s stale ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s stale -format json main.go\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的输出格式 %q\n", *format)
+		return 1
+	}
+
+	var diagnostics []getcomments.Diagnostic
+	input := fs.Arg(0)
+	if getcomments.IsDirPattern(input) {
+		report, err := getcomments.FindStaleRefsDir(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "检查过时引用失败: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range report.Errors {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+		}
+		diagnostics = report.Diagnostics
+	} else {
+		var err error
+		if diagnostics, err = getcomments.FindStaleRefs(getcomments.FromFile(input)); err != nil {
+			fmt.Fprintf(os.Stderr, "检查过时引用失败: %v\n", err)
+			return 1
+		}
+	}
+
+	if err := writeDiagnostics(*format, diagnostics); err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	if len(diagnostics) > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runStats 执行 stats 子命令，返回进程退出码
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	format := fs.String("format", "json", "输出格式: json 或 markdown")
+	top := fs.Int("top", 20, "最多列出的函数个数，0表示全部")
+	minLines := fs.Int("min-lines", 0, "只列出不少于该行数的函数")
+	var genMode generated.Mode
+	fs.Var(&genMode, "generated", "生成文件的处理方式: skip 跳过、mark 照常统计、refuse 报错退出（默认目录模式下跳过，显式指定的文件照常统计）")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s stats [选项] <文件路径|目录|目录/...>\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "统计每个文件与函数的代码行、注释行、注释密度、文档与函数内注释、中日韩文字与拉丁字母的比例以及注释平均长度，\n")
+		fmt.Fprintf(os.Stderr, "函数按 代码行 * (1 - 注释密度) 从大到小排列，长且注释少的函数在前；_test.go 文件不参与统计\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s stats -format markdown ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s stats -top 10 -min-lines 30 ./...\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	var report *getcomments.StatsReport
+	input := fs.Arg(0)
+	mode := generatedModeFor(genMode, input)
+	extractor := getcomments.NewExtractor(getcomments.WithGenerated(mode))
+	if getcomments.IsDirPattern(input) {
+		var err error
+		if report, err = extractor.CommentStatsDir(input); err != nil {
+			fmt.Fprintf(os.Stderr, "统计注释失败: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range report.Errors {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+		}
+		reportSkipped(report.Skipped)
+	} else {
+		fileStats, err := extractor.CommentStats(getcomments.FromFile(input))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "统计注释失败: %v\n", err)
+			return 1
+		}
+		var files []getcomments.FileStats
+		if !skippedGeneratedFile(mode, input) {
+			files = append(files, *fileStats)
+		}
+		report = getcomments.NewStatsReport(files)
+	}
+
+	// 只保留排在前面的函数
+	functions := report.Functions[:0]
+	for _, fn := range report.Functions {
+		if fn.Lines >= *minLines && (*top <= 0 || len(functions) < *top) {
+			functions = append(functions, fn)
+		}
+	}
+	report.Functions = functions
+
+	var err error
+	switch *format {
+	case "json":
+		var output []byte
+		if output, err = json.MarshalIndent(report, "", "    "); err == nil {
+			fmt.Println(string(output))
+		}
+	case "markdown":
+		writeStatsMarkdown(os.Stdout, report)
+	default:
+		err = fmt.Errorf("不支持的输出格式 %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func writeStatsMarkdown(w io.Writer, report *getcomments.StatsReport) {
+	fmt.Fprintf(w, "# 注释密度: %.2f (注释 %d 行 / 代码 %d 行)\n\n", report.Density, report.CommentLines, report.CodeLines)
+	fmt.Fprintf(w, "文档注释 %d 行，函数内注释 %d 行，共 %d 条注释，平均 %.1f 字，中日韩文字占 %.0f%%\n\n",
+		report.DocLines, report.InlineLines, report.Comments, report.AvgLength, report.CJKRatio*100)
+
+	if len(report.Functions) > 0 {
+		fmt.Fprintf(w, "## 注释最少的函数\n\n")
+		fmt.Fprintf(w, "| 位置 | 函数 | 行数 | 代码行 | 注释行 | 密度 | 文档/函数内 | 得分 |\n")
+		fmt.Fprintf(w, "|------|------|------|--------|--------|------|-------------|------|\n")
+		for _, fn := range report.Functions {
+			fmt.Fprintf(w, "| %s:%d | %s | %d | %d | %d | %.2f | %d/%d | %.1f |\n",
+				fn.File, fn.Pos.Line, fn.Name, fn.Lines, fn.CodeLines, fn.CommentLines, fn.Density, fn.DocLines, fn.InlineLines, fn.Score)
+		}
+		fmt.Fprintln(w)
+	}
+
+	fmt.Fprintf(w, "## 文件\n\n")
+	fmt.Fprintf(w, "| 文件 | 代码行 | 注释行 | 密度 | 文档/函数内 | 注释条数 | 平均长度 | 中日韩文字占比 |\n")
+	fmt.Fprintf(w, "|------|--------|--------|------|-------------|----------|----------|----------------|\n")
+	for _, file := range report.Files {
+		fmt.Fprintf(w, "| %s | %d | %d | %.2f | %d/%d | %d | %.1f | %.0f%% |\n",
+			file.File, file.CodeLines, file.CommentLines, file.Density, file.DocLines, file.InlineLines, file.Comments, file.AvgLength, file.CJKRatio*100)
+	}
+}
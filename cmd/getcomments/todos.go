@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runTodos 执行 todos 子命令，返回进程退出码
+func runTodos(args []string) int {
+	fs := flag.NewFlagSet("todos", flag.ExitOnError)
+	groupBy := fs.String("group-by", "marker", "分组方式: owner、package 或 marker")
+	format := fs.String("format", "json", "输出格式: json、markdown 或 csv")
+	failOn := fs.String("fail-on", "", "超过阈值时以退出码1结束，如 FIXME 或 FIXME=0,TODO=20")
+	baselinePath := fs.String("baseline", "", "之前 -format json 的输出，只统计其中没有的新增标记")
+	name := fs.String("name", "stdin.go", "标准输入使用的文件名")
+	var genMode generated.Mode
+	fs.Var(&genMode, "generated", "生成文件的处理方式: skip 跳过、mark 照常查找、refuse 报错退出（默认目录模式下跳过，显式指定的文件照常查找）")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s todos [选项] <文件路径|目录|目录/...|->\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "查找 %s 标记\n\n选项:\n", strings.Join(getcomments.TodoMarkers, "/"))
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s todos -group-by owner -format markdown ./...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s todos -baseline todos.json -fail-on FIXME ./...\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	if *groupBy != "owner" && *groupBy != "package" && *groupBy != "marker" {
+		fmt.Fprintf(os.Stderr, "错误: 不支持的分组方式 %q\n", *groupBy)
+		return 1
+	}
+	thresholds, err := parseFailOn(*failOn)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	var todos []getcomments.Todo
+	input := fs.Arg(0)
+	mode := generatedModeFor(genMode, input)
+	extractor := getcomments.NewExtractor(getcomments.WithGenerated(mode))
+	switch {
+	case input == "-":
+		todos, err = extractor.FindTodos(getcomments.FromReader(*name, os.Stdin))
+	case getcomments.IsDirPattern(input):
+		var result *getcomments.TodoResult
+		result, err = extractor.FindTodosDir(input)
+		if err == nil {
+			for _, fileErr := range result.Errors {
+				fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+			}
+			reportSkipped(result.Skipped)
+			todos = result.Todos
+		}
+	default:
+		if todos, err = extractor.FindTodos(getcomments.FromFile(input)); err == nil {
+			skippedGeneratedFile(mode, input)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "查找待办标记失败: %v\n", err)
+		return 1
+	}
+
+	groups := getcomments.GroupTodos(todos, *groupBy)
+	switch *format {
+	case "json":
+		err = writeTodosJSON(os.Stdout, groups)
+	case "markdown":
+		err = writeTodosMarkdown(os.Stdout, groups)
+	case "csv":
+		err = writeTodosCSV(os.Stdout, groups)
+	default:
+		err = fmt.Errorf("不支持的输出格式 %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+		return 1
+	}
+
+	if len(thresholds) == 0 {
+		return 0
+	}
+	checked := todos
+	if *baselinePath != "" {
+		baseline, err := readTodosBaseline(*baselinePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		checked = getcomments.NewTodos(todos, baseline)
+	}
+	return checkThresholds(checked, thresholds)
+}
+
+// parseFailOn 解析 -fail-on 参数，如 "FIXME" 或 "FIXME=0,TODO=20"，未写阈值时为0
+func parseFailOn(s string) (map[string]int, error) {
+	thresholds := make(map[string]int)
+	if s == "" {
+		return thresholds, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		marker, limit, hasLimit := strings.Cut(strings.TrimSpace(item), "=")
+		marker = strings.ToUpper(marker)
+		if !isTodoMarker(marker) {
+			return nil, fmt.Errorf("无效的 -fail-on 标记 %q，可选值为 %s", marker, strings.Join(getcomments.TodoMarkers, "、"))
+		}
+		n := 0
+		if hasLimit {
+			var err error
+			if n, err = strconv.Atoi(limit); err != nil || n < 0 {
+				return nil, fmt.Errorf("无效的 -fail-on 阈值 %q", item)
+			}
+		}
+		thresholds[marker] = n
+	}
+	return thresholds, nil
+}
+
+func isTodoMarker(marker string) bool {
+	for _, m := range getcomments.TodoMarkers {
+		if m == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// checkThresholds 统计各标记的数量，任一标记超过阈值时返回1
+func checkThresholds(todos []getcomments.Todo, thresholds map[string]int) int {
+	counts := make(map[string]int)
+	for _, todo := range todos {
+		counts[todo.Marker]++
+	}
+	code := 0
+	for _, marker := range getcomments.TodoMarkers {
+		limit, ok := thresholds[marker]
+		if ok && counts[marker] > limit {
+			fmt.Fprintf(os.Stderr, "%s 数量为 %d，超过阈值 %d\n", marker, counts[marker], limit)
+			code = 1
+		}
+	}
+	return code
+}
+
+// readTodosBaseline 读取之前以JSON格式输出的分组结果
+func readTodosBaseline(path string) ([]getcomments.Todo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取基线文件失败: %v", err)
+	}
+	var groups []getcomments.TodoGroup
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("解析基线文件失败: %v", err)
+	}
+	var todos []getcomments.Todo
+	for _, g := range groups {
+		todos = append(todos, g.Todos...)
+	}
+	return todos, nil
+}
+
+func writeTodosJSON(w io.Writer, groups []getcomments.TodoGroup) error {
+	if groups == nil {
+		groups = []getcomments.TodoGroup{}
+	}
+	output, err := json.MarshalIndent(groups, "", "    ")
+	if err != nil {
+		return fmt.Errorf("序列化结果失败: %v", err)
+	}
+	_, err = fmt.Fprintln(w, string(output))
+	return err
+}
+
+func writeTodosMarkdown(w io.Writer, groups []getcomments.TodoGroup) error {
+	for _, g := range groups {
+		fmt.Fprintf(w, "## %s (%d)\n\n", groupLabel(g.Key), g.Count)
+		fmt.Fprintf(w, "| 位置 | 标记 | 负责人 | 函数 | 问题 | 说明 |\n")
+		fmt.Fprintf(w, "|------|------|--------|------|------|------|\n")
+		for _, todo := range g.Todos {
+			fmt.Fprintf(w, "| %s:%d | %s | %s | %s | %s | %s |\n",
+				todo.File, todo.Pos.Line, todo.Marker, todo.Owner, todo.Func,
+				strings.Join(todo.Issues, " "), strings.ReplaceAll(todo.Text, "|", `\|`))
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func writeTodosCSV(w io.Writer, groups []getcomments.TodoGroup) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"group", "file", "line", "column", "marker", "owner", "func", "issues", "text"})
+	for _, g := range groups {
+		for _, todo := range g.Todos {
+			cw.Write([]string{
+				g.Key, todo.File, strconv.Itoa(todo.Pos.Line), strconv.Itoa(todo.Pos.Column),
+				todo.Marker, todo.Owner, todo.Func, strings.Join(todo.Issues, " "), todo.Text,
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// groupLabel 返回Markdown中分组的标题，空键表示未指定
+func groupLabel(key string) string {
+	if key == "" {
+		return "(未指定)"
+	}
+	return key
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+	"github.com/monshunter/ast-practice/pkg/getcomments"
+)
+
+// runTranslate 执行 translate 子命令，返回进程退出码
+func runTranslate(args []string) int {
+	fs := flag.NewFlagSet("translate", flag.ExitOnError)
+	glossary := fs.String("glossary", "", "本地词汇表（JSON对象: {\"原文\": \"译文\"}）")
+	url := fs.String("url", "", "HTTP JSON翻译服务地址")
+	source := fs.String("source", "zh", "源语言，传给翻译服务")
+	target := fs.String("target", "en", "目标语言，传给翻译服务")
+	cachePath := fs.String("cache", "", "翻译缓存文件，存在时先加载，结束后保存")
+	write := fs.Bool("w", false, "将译文写回原文件")
+	outDir := fs.String("o", "", "将译文写入该目录下的平行目录树")
+	timeout := fs.Duration("timeout", time.Minute, "每个文件的翻译超时时间")
+	name := fs.String("name", "stdin.go", "标准输入使用的文件名")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: %s translate (-glossary 文件|-url 地址) [选项] <文件路径|目录|目录/...|->\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "翻译注释并写回，不指定 -w 或 -o 时输出到标准输出（仅限单个文件）\n\n选项:\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n示例:\n")
+		fmt.Fprintf(os.Stderr, "  %s translate -glossary glossary.json main.go\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s translate -url http://localhost:8080/translate -cache cache.json -o ../en ./...\n", os.Args[0])
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 1
+	}
+	input := fs.Arg(0)
+
+	var inner getcomments.Translator
+	switch {
+	case (*glossary == "") == (*url == ""):
+		fmt.Fprintf(os.Stderr, "错误: 需要且只能指定 -glossary 或 -url 其中之一\n")
+		return 1
+	case *glossary != "":
+		g, err := getcomments.LoadGlossary(*glossary)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		inner = g
+	default:
+		inner = &getcomments.HTTPTranslator{URL: *url, Source: *source, Target: *target, Client: &http.Client{}}
+	}
+	if *write && *outDir != "" {
+		fmt.Fprintf(os.Stderr, "错误: -w 与 -o 不能同时使用\n")
+		return 1
+	}
+	if *write && input == "-" {
+		fmt.Fprintf(os.Stderr, "错误: 标准输入不能使用 -w\n")
+		return 1
+	}
+
+	translator := getcomments.NewCachingTranslator(inner)
+	if *cachePath != "" {
+		if err := translator.Load(*cachePath); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+	}
+
+	var inputs []getcomments.Input
+	switch {
+	case input == "-":
+		inputs = []getcomments.Input{getcomments.FromReader(*name, os.Stdin)}
+	case getcomments.IsDirPattern(input):
+		if !*write && *outDir == "" {
+			fmt.Fprintf(os.Stderr, "错误: 目录模式需要 -w 或 -o\n")
+			return 1
+		}
+		dir, err := getcomments.ListDir(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+		for _, fileErr := range dir.Errors {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %v\n", fileErr)
+		}
+		inputs = dir.Inputs
+	default:
+		inputs = []getcomments.Input{getcomments.FromFile(input)}
+	}
+
+	code := 0
+	for _, in := range inputs {
+		if err := translateFile(translator, in, *write, *outDir, *timeout); err != nil {
+			fmt.Fprintf(os.Stderr, "警告: 跳过 %s: %v\n", in.Filename(), err)
+			code = 1
+		}
+	}
+
+	if *cachePath != "" {
+		if err := translator.Save(*cachePath); err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			return 1
+		}
+	}
+	return code
+}
+
+// translateFile 翻译一个文件的注释，按选项写回原文件、写入平行目录树或输出到标准输出
+func translateFile(translator getcomments.Translator, in getcomments.Input, write bool, outDir string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result, err := getcomments.TranslateComments(ctx, in, translator)
+	if err != nil {
+		return err
+	}
+	for _, conflict := range result.Conflicts {
+		fmt.Fprintf(os.Stderr, "警告: 未翻译 %v\n", conflict)
+	}
+
+	switch {
+	case write:
+		if result.Applied == 0 {
+			return nil
+		}
+		return writeFilePreservingMode(in.Path(), result.Source)
+	case outDir != "":
+		path := filepath.Join(outDir, filepath.FromSlash(in.Filename()))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+		if err := os.WriteFile(path, result.Source, 0644); err != nil {
+			return fmt.Errorf("写入文件失败: %v", err)
+		}
+		return nil
+	default:
+		_, err := os.Stdout.Write(result.Source)
+		return err
+	}
+}
+
+// writeFilePreservingMode 写回文件并保留原有的权限，拒绝改写生成文件
+func writeFilePreservingMode(path string, content []byte) error {
+	isGenerated, err := generated.IsGeneratedFile(path)
+	if err != nil {
+		return err
+	}
+	if _, err := generated.Refuse.Check(path, isGenerated); err != nil {
+		return err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.WriteFile(path, content, mode); err != nil {
+		return fmt.Errorf("写入文件失败: %v", err)
+	}
+	return nil
+}
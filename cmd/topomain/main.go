@@ -9,30 +9,42 @@ import (
 	"go/token"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/monshunter/ast-practice/pkg/generated"
 	"golang.org/x/mod/modfile"
 )
 
 // MainPackageInfo 表示一个main包的信息
 type MainPackageInfo struct {
-	MainDir  string   `json:"mainDir"`
-	MainFile string   `json:"mainFile"`
-	Imports  []string `json:"imports"`
+	MainDir   string   `json:"mainDir"`
+	MainFile  string   `json:"mainFile"`
+	Imports   []string `json:"imports"`
+	Generated []string `json:"generated,omitempty"` // main包及其依赖中的生成文件
 }
 
 // 全局变量
 var (
 	projectRoot  string
 	modulePrefix string
+	genMode      = generated.Mark
 )
 
+func init() {
+	flag.Var(&genMode, "generated", "生成文件的处理方式: mark 标记、skip 跳过、refuse 报错退出")
+}
+
 func main() {
 	flag.Parse()
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "用法: %s [-generated mark|skip|refuse] <项目路径>\n", os.Args[0])
+		os.Exit(1)
+	}
 
 	// 获取项目根目录的绝对路径
 	var err error
-	projectRoot, err = filepath.Abs(os.Args[1])
+	projectRoot, err = filepath.Abs(flag.Arg(0))
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "无法获取绝对路径: %v\n", err)
 		os.Exit(1)
@@ -131,8 +143,15 @@ func findMainPackages(goFiles []string) ([]string, error) {
 			continue
 		}
 		fset := token.NewFileSet()
-		node, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly)
+		node, err := parser.ParseFile(fset, file, nil, parser.PackageClauseOnly|parser.ParseComments)
+		if err != nil {
+			continue
+		}
+		skip, err := genMode.Check(file, generated.IsGenerated(node))
 		if err != nil {
+			return nil, err
+		}
+		if skip {
 			continue
 		}
 		if node.Name.Name == "main" {
@@ -151,7 +170,12 @@ func analyzeMainImports(mainDir string) MainPackageInfo {
 	}
 	// 分析导入的包
 	importedPkgs := make(map[string]bool)
-	collectImports(mainDir, importedPkgs)
+	generatedFiles := make(map[string]bool)
+	collectImports(mainDir, importedPkgs, generatedFiles)
+	for file := range generatedFiles {
+		info.Generated = append(info.Generated, file)
+	}
+	sort.Strings(info.Generated)
 
 	// 过滤出项目内部包
 	var internalPackages []string
@@ -188,8 +212,9 @@ func findMainEntryFile(mainDir string) string {
 	return ""
 }
 
-// collectImports 收集目录中所有Go文件的导入
-func collectImports(dir string, importedPkgs map[string]bool) {
+// collectImports 收集目录中所有Go文件的导入，按 -generated 的方式处理生成文件，
+// mark 方式下生成文件记录在generatedFiles中
+func collectImports(dir string, importedPkgs map[string]bool, generatedFiles map[string]bool) {
 	// 获取目录中的所有Go文件
 	entries, err := os.ReadDir(dir)
 	if err != nil {
@@ -208,10 +233,22 @@ func collectImports(dir string, importedPkgs map[string]bool) {
 
 		filePath := filepath.Join(dir, name)
 		fset := token.NewFileSet()
-		node, err := parser.ParseFile(fset, filePath, nil, parser.ImportsOnly)
+		node, err := parser.ParseFile(fset, filePath, nil, parser.ImportsOnly|parser.ParseComments)
 		if err != nil {
 			continue
 		}
+		isGenerated := generated.IsGenerated(node)
+		skip, err := genMode.Check(filePath, isGenerated)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "分析导入错误: %v\n", err)
+			os.Exit(1)
+		}
+		if skip {
+			continue
+		}
+		if isGenerated {
+			generatedFiles[filePath] = true
+		}
 
 		// 添加导入的包
 		for _, imp := range node.Imports {
@@ -224,7 +261,7 @@ func collectImports(dir string, importedPkgs map[string]bool) {
 				importedPkgs[importPath] = true
 				pkgDir := getPackageDir(importPath)
 				if pkgDir != "" && pkgDir != dir {
-					collectImports(pkgDir, importedPkgs)
+					collectImports(pkgDir, importedPkgs, generatedFiles)
 				}
 			}
 		}
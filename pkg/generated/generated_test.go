@@ -0,0 +1,52 @@
+package generated
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+func TestIsGeneratedSource(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"protobuf", "// Code generated by protoc-gen-go. DO NOT EDIT.\n// source: a.proto\n\npackage pb\n", true},
+		{"构建约束之后", "//go:build linux\n\n// Code generated by stringer; DO NOT EDIT.\n\npackage p\n", true},
+		{"package之后", "package p\n\n// Code generated by hand. DO NOT EDIT.\n", false},
+		{"缺少句号", "// Code generated by tool. DO NOT EDIT\npackage p\n", false},
+		{"普通文件", "// Package p 示例\npackage p\n", false},
+		{"无法解析", "// Code generated by tool. DO NOT EDIT.\n\npackage p\n\nfunc {", true},
+	}
+	for _, tt := range tests {
+		if got := IsGeneratedSource([]byte(tt.src)); got != tt.want {
+			t.Errorf("%s: IsGeneratedSource = %v, 期望 %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMode(t *testing.T) {
+	mode := Skip
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&mode, "generated", "")
+	if err := fs.Parse([]string{"-generated", "refuse"}); err != nil || mode != Refuse {
+		t.Fatalf("解析参数后为 %q, 错误 %v", mode, err)
+	}
+	if err := mode.Set("ignore"); err == nil {
+		t.Errorf("不支持的处理方式应当报错")
+	}
+
+	if skip, err := Skip.Check("a.go", true); !skip || err != nil {
+		t.Errorf("Skip: skip=%v err=%v", skip, err)
+	}
+	if skip, err := Mark.Check("a.go", true); skip || err != nil {
+		t.Errorf("Mark: skip=%v err=%v", skip, err)
+	}
+	if _, err := Refuse.Check("a.go", true); !errors.Is(err, ErrGenerated) {
+		t.Errorf("Refuse: err=%v", err)
+	}
+	if skip, err := Refuse.Check("a.go", false); skip || err != nil {
+		t.Errorf("非生成文件: skip=%v err=%v", skip, err)
+	}
+}
@@ -0,0 +1,229 @@
+package getcomments
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ApplyResult 是将编辑后的注释写回源代码的结果
+type ApplyResult struct {
+	Source    []byte          `json:"-"`                   // 写回后的源代码
+	Applied   int             `json:"applied"`             // 实际修改的注释数
+	Conflicts []ApplyConflict `json:"conflicts,omitempty"` // 无法应用的编辑
+}
+
+// ApplyConflict 描述一条无法应用的编辑，对应的注释保持不变
+type ApplyConflict struct {
+	Key    string `json:"key"`    // 编辑对应的"文件名:行号"键
+	Reason string `json:"reason"` // 无法应用的原因
+}
+
+// Error 实现error接口
+func (c ApplyConflict) Error() string {
+	return fmt.Sprintf("%s: %s", c.Key, c.Reason)
+}
+
+// commentEdit 是对源代码中一条注释的替换
+type commentEdit struct {
+	start, end int // 原注释的字节偏移范围
+	raw        string
+}
+
+// ApplyComments 将编辑后的注释映射写回源代码
+// edits 的键与ExtractComments的结果相同，只处理文件名与输入一致的键，其余键会被忽略。
+// 每个键下注释的数量与顺序必须与当前源代码一致，只替换注释文本，代码、格式与注释位置保持不变。
+// 键已经对不上的编辑不会被猜测应用，而是记录在Conflicts中。
+// 键只包含行号，源代码在提取后发生变化时无法完全确认对应关系，此时应使用ApplyCommentRecords
+func ApplyComments(in Input, edits CommentsMap) (*ApplyResult, error) {
+	return defaultExtractor.ApplyComments(in, edits)
+}
+
+// ApplyCommentRecords 将编辑后的注释记录写回源代码
+// 记录按文件名与注释的起止位置匹配，Raw与当前注释不同时使用Raw，否则使用Text并保留原有的注释符号
+func ApplyCommentRecords(in Input, edits CommentRecords) (*ApplyResult, error) {
+	return defaultExtractor.ApplyCommentRecords(in, edits)
+}
+
+// ApplyComments 使用提取器的配置关联注释后写回编辑，提取时使用了过滤选项时应使用相同配置的提取器
+func (e *Extractor) ApplyComments(in Input, edits CommentsMap) (*ApplyResult, error) {
+	parsed, records, err := e.applyBase(in)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[string]CommentRecords)
+	for _, r := range records {
+		current[r.Key()] = append(current[r.Key()], r)
+	}
+
+	keys := make([]string, 0, len(edits))
+	for key := range edits {
+		if file, _, ok := splitKey(key); ok && file == in.Filename() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	result := &ApplyResult{}
+	var changes []commentEdit
+	for _, key := range keys {
+		existing := current[key]
+		switch {
+		case len(existing) == 0:
+			result.conflict(key, "该行没有关联的注释")
+			continue
+		case len(existing) != len(edits[key]):
+			result.conflict(key, fmt.Sprintf("注释数量不一致: 源代码中有 %d 条，编辑中有 %d 条", len(existing), len(edits[key])))
+			continue
+		}
+		var pending []commentEdit
+		var reason string
+		for i, r := range existing {
+			var edit commentEdit
+			edit, reason = newCommentEdit(parsed, r, edits[key][i])
+			if reason != "" {
+				break
+			}
+			pending = append(pending, edit)
+		}
+		if reason != "" {
+			result.conflict(key, reason)
+			continue
+		}
+		changes = append(changes, pending...)
+	}
+	if err := result.apply(parsed, changes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ApplyCommentRecords 使用提取器的配置写回编辑后的注释记录
+func (e *Extractor) ApplyCommentRecords(in Input, edits CommentRecords) (*ApplyResult, error) {
+	parsed, records, err := e.applyBase(in)
+	if err != nil {
+		return nil, err
+	}
+	current := make(map[Position]CommentRecord)
+	for _, r := range records {
+		current[r.Start] = r
+	}
+
+	result := &ApplyResult{}
+	var changes []commentEdit
+	for _, edit := range edits {
+		if edit.File != in.Filename() {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d:%d", edit.File, edit.Start.Line, edit.Start.Column)
+		r, ok := current[edit.Start]
+		if !ok || r.End != edit.End {
+			result.conflict(key, "该位置没有对应的注释")
+			continue
+		}
+		if edit.Node != (NodeInfo{}) && edit.Node != r.Node {
+			result.conflict(key, fmt.Sprintf("注释关联的节点已变化: 原为 %v，现为 %v", edit.Node, r.Node))
+			continue
+		}
+		raw := edit.Raw
+		if raw == r.Raw && edit.Text != r.Text {
+			raw = edit.Text
+		}
+		change, reason := newCommentEdit(parsed, r, raw)
+		if reason != "" {
+			result.conflict(key, reason)
+			continue
+		}
+		changes = append(changes, change)
+	}
+	if err := result.apply(parsed, changes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyBase 解析输入并关联注释，得到编辑需要匹配的当前注释
+func (e *Extractor) applyBase(in Input) (*ParsedFile, CommentRecords, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parsed, e.associate(in.Filename(), parsed), nil
+}
+
+// newCommentEdit 检查编辑后的注释并生成替换
+// 没有注释符号的文本会沿用原注释的符号；替换不能改变注释的行数，否则之后的代码位置会发生变化。
+// 块注释不能替换为 // 注释：块注释后面同一行的代码（如 "/* c */ y = 2"）会被注释掉，而结果仍然可以解析
+func newCommentEdit(parsed *ParsedFile, r CommentRecord, edited string) (commentEdit, string) {
+	raw := edited
+	if !strings.HasPrefix(raw, "//") && !strings.HasPrefix(raw, "/*") {
+		if r.Block {
+			raw = "/* " + raw + " */"
+		} else {
+			raw = "// " + raw
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(raw, "//") && r.Block:
+		return commentEdit{}, "块注释不能替换为 // 注释"
+	case strings.HasPrefix(raw, "//"):
+		if strings.Contains(raw, "\n") {
+			return commentEdit{}, "// 注释不能包含换行"
+		}
+	case !strings.HasSuffix(raw, "*/") || len(raw) < 4 || strings.Contains(raw[2:len(raw)-2], "*/"):
+		return commentEdit{}, "块注释必须以 */ 结尾且中间不能包含 */"
+	}
+	if strings.Count(raw, "\n") != strings.Count(r.Raw, "\n") {
+		return commentEdit{}, fmt.Sprintf("注释行数不一致: 原注释 %d 行，编辑后 %d 行",
+			strings.Count(r.Raw, "\n")+1, strings.Count(raw, "\n")+1)
+	}
+
+	return commentEdit{start: offsetOf(parsed, r.Start), end: offsetOf(parsed, r.End), raw: raw}, ""
+}
+
+// offsetOf 将行列位置转换为字节偏移
+func offsetOf(parsed *ParsedFile, pos Position) int {
+	file := parsed.Fset.File(parsed.File.Pos())
+	return file.Offset(file.LineStart(pos.Line)) + pos.Column - 1
+}
+
+// apply 按偏移从后向前替换注释，并确认结果仍然是合法的Go代码
+func (r *ApplyResult) apply(parsed *ParsedFile, changes []commentEdit) error {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].start > changes[j].start
+	})
+	src := append([]byte(nil), parsed.Content...)
+	for _, c := range changes {
+		if string(src[c.start:c.end]) == c.raw {
+			continue
+		}
+		src = append(src[:c.start], append([]byte(c.raw), src[c.end:]...)...)
+		r.Applied++
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments); err != nil {
+		return fmt.Errorf("写回注释后代码无法解析: %v", err)
+	}
+	r.Source = src
+	return nil
+}
+
+func (r *ApplyResult) conflict(key, reason string) {
+	r.Conflicts = append(r.Conflicts, ApplyConflict{Key: key, Reason: reason})
+}
+
+// splitKey 拆分"文件名:行号"键
+func splitKey(key string) (string, int, bool) {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(key[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return key[:i], line, true
+}
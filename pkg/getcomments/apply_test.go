@@ -0,0 +1,143 @@
+package getcomments
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const applyTestCode = `package p
+
+// Add 两数相加
+func Add(a, b int) int {
+	/* 求和 */
+	return a + b // 返回结果
+}
+
+// Sub 两数相减
+// 第二行
+func Sub(a, b int) int { return a - b }
+`
+
+func TestApplyComments(t *testing.T) {
+	in := FromSource("p.go", []byte(applyTestCode))
+	edits := CommentsMap{
+		"p.go:4":     {"// Add adds two numbers"},
+		"p.go:6":     {"/* sum */", "return the result"},
+		"p.go:11":    {"// Sub subtracts b from a"},
+		"p.go:99":    {"// 不存在的行"},
+		"other.go:4": {"// 其他文件的键被忽略"},
+	}
+
+	result, err := ApplyComments(in, edits)
+	if err != nil {
+		t.Fatalf("写回注释失败: %v", err)
+	}
+	want := strings.NewReplacer(
+		"// Add 两数相加", "// Add adds two numbers",
+		"/* 求和 */", "/* sum */",
+		"// 返回结果", "// return the result",
+	).Replace(applyTestCode)
+	if string(result.Source) != want {
+		t.Errorf("写回后的代码为\n%s\n期望\n%s", result.Source, want)
+	}
+	if result.Applied != 3 {
+		t.Errorf("修改的注释数为 %d, 期望 3", result.Applied)
+	}
+	wantConflicts := []ApplyConflict{
+		{Key: "p.go:11", Reason: "注释数量不一致: 源代码中有 2 条，编辑中有 1 条"},
+		{Key: "p.go:99", Reason: "该行没有关联的注释"},
+	}
+	if !reflect.DeepEqual(result.Conflicts, wantConflicts) {
+		t.Errorf("冲突为 %v, 期望 %v", result.Conflicts, wantConflicts)
+	}
+}
+
+func TestApplyCommentsRejectsLineChanges(t *testing.T) {
+	result, err := ApplyComments(FromSource("p.go", []byte(applyTestCode)), CommentsMap{
+		"p.go:4": {"// 第一行\n// 第二行"},
+		"p.go:6": {"/* 多行\n块注释 */", "// 返回结果"},
+	})
+	if err != nil {
+		t.Fatalf("写回注释失败: %v", err)
+	}
+	if string(result.Source) != applyTestCode || result.Applied != 0 || len(result.Conflicts) != 2 {
+		t.Errorf("改变行数的编辑不应被应用: applied=%d conflicts=%v", result.Applied, result.Conflicts)
+	}
+}
+
+// TestApplyCommentsBlockWithCode 块注释后面同一行有代码时，替换为 // 注释会把代码注释掉
+func TestApplyCommentsBlockWithCode(t *testing.T) {
+	code := "package p\n\nfunc f() (y int) {\n\t/* c */ y = 2\n\treturn\n}\n"
+	in := FromSource("p.go", []byte(code))
+	for _, edit := range []string{"// c", "// c\n"} {
+		result, err := ApplyComments(in, CommentsMap{"p.go:4": {edit}})
+		if err != nil {
+			t.Fatalf("写回注释失败: %v", err)
+		}
+		if string(result.Source) != code || result.Applied != 0 || len(result.Conflicts) != 1 {
+			t.Errorf("编辑 %q 不应被应用: applied=%d conflicts=%v\n%s", edit, result.Applied, result.Conflicts, result.Source)
+		}
+	}
+
+	// 没有注释符号的文本保留块注释的形式
+	result, err := ApplyComments(in, CommentsMap{"p.go:4": {"comment"}})
+	if err != nil {
+		t.Fatalf("写回注释失败: %v", err)
+	}
+	if want := strings.Replace(code, "/* c */", "/* comment */", 1); string(result.Source) != want {
+		t.Errorf("写回后的代码为\n%s\n期望\n%s", result.Source, want)
+	}
+
+	records, err := ExtractCommentRecordsFrom(in)
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	records[0].Raw = "// c"
+	result, err = ApplyCommentRecords(in, records)
+	if err != nil {
+		t.Fatalf("写回注释失败: %v", err)
+	}
+	if string(result.Source) != code || len(result.Conflicts) != 1 {
+		t.Errorf("记录中的 // 注释不应替换块注释: conflicts=%v\n%s", result.Conflicts, result.Source)
+	}
+}
+
+func TestApplyCommentRecordsRoundTrip(t *testing.T) {
+	in := FromSource("p.go", []byte(applyTestCode))
+	records, err := ExtractCommentRecordsFrom(in)
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+
+	// 原样写回不改变代码
+	result, err := ApplyCommentRecords(in, records)
+	if err != nil {
+		t.Fatalf("写回注释失败: %v", err)
+	}
+	if string(result.Source) != applyTestCode || result.Applied != 0 || len(result.Conflicts) != 0 {
+		t.Fatalf("原样写回改变了代码: applied=%d conflicts=%v", result.Applied, result.Conflicts)
+	}
+
+	for i := range records {
+		switch records[i].Text {
+		case "第二行":
+			records[i].Text = "second line"
+		case "求和":
+			records[i].Raw = "/* sum! */"
+		case "返回结果":
+			records[i].Start.Column++ // 位置对不上的记录
+		}
+	}
+	result, err = ApplyCommentRecords(in, records)
+	if err != nil {
+		t.Fatalf("写回注释失败: %v", err)
+	}
+	want := strings.NewReplacer("// 第二行", "// second line", "/* 求和 */", "/* sum! */").Replace(applyTestCode)
+	if string(result.Source) != want {
+		t.Errorf("写回后的代码为\n%s\n期望\n%s", result.Source, want)
+	}
+	if len(result.Conflicts) != 1 || result.Conflicts[0].Reason != "该位置没有对应的注释" {
+		t.Errorf("冲突为 %v", result.Conflicts)
+	}
+}
@@ -0,0 +1,218 @@
+package getcomments
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// BatchOptions 并发批量提取的配置
+type BatchOptions struct {
+	Workers int  // 并发提取的goroutine数，不大于0时为runtime.GOMAXPROCS(0)
+	Ordered bool // 按输入顺序交付结果，否则按完成顺序交付
+	// MaxBytes 同时处理的源代码总字节数上限，0表示不限制
+	// 解析后的AST通常占用源代码数倍的内存，该上限限制的是同时存在的AST规模；
+	// 单个文件超过上限时等其他文件处理完后单独处理
+	MaxBytes int64
+}
+
+// BatchResult 批量提取中一个文件的结果
+type BatchResult struct {
+	Index     int            // 输入在列表中的序号
+	File      string         // 输入的文件名
+	Records   CommentRecords // 注释记录，容错模式下有语法错误时为部分结果
+	Generated bool           // 是否是生成文件
+	Skipped   bool           // 是否按generated.Skip跳过
+	Err       error          // 读取、解析或拒绝处理生成文件的错误，容错模式下可能是SyntaxErrors
+}
+
+// ExtractBatch 使用默认配置并发提取多个输入的注释
+func ExtractBatch(ctx context.Context, inputs []Input, opts BatchOptions, fn func(BatchResult) error) error {
+	return defaultExtractor.ExtractBatch(ctx, inputs, opts, fn)
+}
+
+// ExtractDirParallel 使用默认配置按目录模式并发提取注释
+func ExtractDirParallel(ctx context.Context, pattern string, opts BatchOptions) (*DirResult, error) {
+	return defaultExtractor.ExtractDirParallel(ctx, pattern, opts)
+}
+
+// ExtractBatch 使用提取器的配置并发提取多个输入的注释，每个结果交给fn处理
+// fn 总是在调用ExtractBatch的goroutine中依次调用，不需要加锁；fn 返回错误时停止提取并返回该错误。
+// ctx 取消时正在等待的文件不再处理，正在读取、解析或关联注释的文件立即放弃，ExtractBatch 返回ctx.Err()，
+// 不等待被放弃的解析完成（go/parser 无法中途停止，这些解析在后台完成后结果被丢弃）。
+// Ordered 为true时结果按输入顺序交付，先完成的结果最多缓存2*Workers个
+func (e *Extractor) ExtractBatch(ctx context.Context, inputs []Input, opts BatchOptions, fn func(BatchResult) error) error {
+	if len(inputs) == 0 {
+		return ctx.Err()
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(inputs))
+
+	ctx, cancel := context.WithCancel(ctx)
+	jobs := make(chan int)
+	results := make(chan BatchResult)
+	limiter := newByteLimiter(opts.MaxBytes)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				item := e.extractLimited(ctx, i, inputs[i], limiter)
+				select {
+				case results <- item:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	defer func() {
+		close(jobs)
+		cancel()
+		wg.Wait()
+	}()
+
+	// next 是下一个分发的序号，deliver 是有序交付时下一个交付的序号
+	next, deliver, done := 0, 0, 0
+	pending := make(map[int]BatchResult)
+	window := 2 * workers
+	for done < len(inputs) {
+		var send chan int
+		if next < len(inputs) && (!opts.Ordered || next < deliver+window) {
+			send = jobs
+		}
+		select {
+		case send <- next:
+			next++
+		case item := <-results:
+			done++
+			if !opts.Ordered {
+				if err := fn(item); err != nil {
+					return err
+				}
+				continue
+			}
+			pending[item.Index] = item
+			for {
+				item, ok := pending[deliver]
+				if !ok {
+					break
+				}
+				delete(pending, deliver)
+				deliver++
+				if err := fn(item); err != nil {
+					return err
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// ExtractDirParallel 使用提取器的配置按目录模式并发提取注释，pattern 的规则与ExtractDir相同
+// 结果总是按文件顺序合并，因此与ExtractDir的结果一致；opts.Ordered 会被忽略
+func (e *Extractor) ExtractDirParallel(ctx context.Context, pattern string, opts BatchOptions) (*DirResult, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	result := &DirResult{Root: dir.Root, Errors: dir.Errors}
+	opts.Ordered = true
+	if err := e.ExtractBatch(ctx, dir.Inputs, opts, result.add); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// extractItem 提取一个输入，结果中记录是否是生成文件以及是否被跳过
+func (e *Extractor) extractItem(ctx context.Context, index int, in Input) BatchResult {
+	records, isGenerated, err := e.extract(ctx, in)
+	return BatchResult{
+		Index:     index,
+		File:      in.Filename(),
+		Records:   records,
+		Generated: isGenerated,
+		Skipped:   isGenerated && err == nil && e.gen == generated.Skip,
+		Err:       err,
+	}
+}
+
+// extractLimited 在内存上限允许时提取一个输入
+func (e *Extractor) extractLimited(ctx context.Context, index int, in Input, limiter *byteLimiter) BatchResult {
+	size := in.size()
+	if err := limiter.acquire(ctx, size); err != nil {
+		return BatchResult{Index: index, File: in.Filename(), Err: err}
+	}
+	defer limiter.release(size)
+	return e.extractItem(ctx, index, in)
+}
+
+// size 返回输入源代码的字节数，无法预先知道时为0
+func (in Input) size() int64 {
+	switch {
+	case in.path != "":
+		if info, err := os.Stat(in.path); err == nil {
+			return info.Size()
+		}
+		return 0
+	case in.reader != nil:
+		return 0
+	default:
+		return int64(len(in.src))
+	}
+}
+
+// byteLimiter 限制同时处理的源代码字节数，max 为0时不限制
+type byteLimiter struct {
+	mu       sync.Mutex
+	max      int64
+	used     int64
+	released chan struct{} // 每次释放时关闭并替换，用于唤醒等待者
+}
+
+func newByteLimiter(max int64) *byteLimiter {
+	return &byteLimiter{max: max, released: make(chan struct{})}
+}
+
+// acquire 等待直到可以再处理n字节；没有其他文件在处理时总是允许，避免超过上限的单个文件永远等待
+func (l *byteLimiter) acquire(ctx context.Context, n int64) error {
+	if l.max <= 0 {
+		return ctx.Err()
+	}
+	for {
+		l.mu.Lock()
+		if l.used == 0 || l.used+n <= l.max {
+			l.used += n
+			l.mu.Unlock()
+			return nil
+		}
+		released := l.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (l *byteLimiter) release(n int64) {
+	if l.max <= 0 {
+		return
+	}
+	l.mu.Lock()
+	l.used -= n
+	close(l.released)
+	l.released = make(chan struct{})
+	l.mu.Unlock()
+}
@@ -0,0 +1,174 @@
+package getcomments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 生成多个大小不同的输入
+func batchTestInputs(n int) []Input {
+	inputs := make([]Input, n)
+	for i := range inputs {
+		src := fmt.Sprintf("package p\n\n// F%d 函数\nfunc F%d() {\n", i, i)
+		for j := 0; j < (n-i)*20; j++ {
+			src += fmt.Sprintf("\t_ = %d // 第%d行\n", j, j)
+		}
+		src += "}\n"
+		inputs[i] = FromSource(fmt.Sprintf("f%d.go", i), []byte(src))
+	}
+	return inputs
+}
+
+func TestExtractBatchOrdered(t *testing.T) {
+	inputs := batchTestInputs(20)
+	var got []int
+	err := ExtractBatch(context.Background(), inputs, BatchOptions{Workers: 4, Ordered: true}, func(r BatchResult) error {
+		if r.Err != nil {
+			return r.Err
+		}
+		want, _ := NewExtractor().Extract(inputs[r.Index])
+		if !reflect.DeepEqual(r.Records, want) {
+			t.Errorf("%s 的结果与顺序提取不一致", r.File)
+		}
+		got = append(got, r.Index)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractBatch出错: %v", err)
+	}
+	for i, index := range got {
+		if index != i {
+			t.Fatalf("交付顺序 = %v, 期望按输入顺序", got)
+		}
+	}
+	if len(got) != len(inputs) {
+		t.Errorf("交付了 %d 个结果, 期望 %d", len(got), len(inputs))
+	}
+}
+
+func TestExtractBatchUnordered(t *testing.T) {
+	inputs := batchTestInputs(20)
+	seen := make(map[int]bool)
+	// 内存上限小于任何一个文件时，文件逐个处理但仍然全部完成
+	err := ExtractBatch(context.Background(), inputs, BatchOptions{Workers: 8, MaxBytes: 1}, func(r BatchResult) error {
+		if r.Err != nil {
+			return r.Err
+		}
+		seen[r.Index] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ExtractBatch出错: %v", err)
+	}
+	if len(seen) != len(inputs) {
+		t.Errorf("交付了 %d 个结果, 期望 %d", len(seen), len(inputs))
+	}
+}
+
+func TestExtractBatchStop(t *testing.T) {
+	inputs := batchTestInputs(50)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := ExtractBatch(ctx, inputs, BatchOptions{Workers: 2}, func(r BatchResult) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("取消后期望 context.Canceled, 得到 %v", err)
+	}
+	if calls >= len(inputs) {
+		t.Errorf("取消后仍处理了全部 %d 个输入", calls)
+	}
+
+	stop := errors.New("停止")
+	calls = 0
+	err = ExtractBatch(context.Background(), inputs, BatchOptions{Workers: 2, Ordered: true}, func(r BatchResult) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("回调返回错误后得到 %v, 调用 %d 次, 期望立即返回该错误", err, calls)
+	}
+}
+
+// TestExtractBatchCancelInFlight 取消时不等待正在读取或解析的文件完成
+func TestExtractBatchCancelInFlight(t *testing.T) {
+	// 读取一直阻塞的输入，模拟很慢的读取或解析
+	pr, pw := io.Pipe()
+	// 2秒后结束读取，没有放弃正在读取的文件时测试以超时失败而不是一直阻塞
+	release := time.AfterFunc(2*time.Second, func() { pw.Close() })
+	defer release.Stop()
+	defer pw.Close()
+	inputs := []Input{FromReader("slow.go", pr), FromSource("fast.go", []byte("package p\n"))}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(50*time.Millisecond, cancel)
+	defer timer.Stop()
+	start := time.Now()
+	err := ExtractBatch(ctx, inputs, BatchOptions{Workers: 2}, func(BatchResult) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("取消后期望 context.Canceled, 得到 %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("取消后 %v 才返回", elapsed)
+	}
+}
+
+func TestExtractDirParallel(t *testing.T) {
+	root := createDirTestProject(t)
+	pattern := filepath.Join(root, "...")
+
+	want, err := ExtractDir(pattern)
+	if err != nil {
+		t.Fatalf("ExtractDir出错: %v", err)
+	}
+	got, err := ExtractDirParallel(context.Background(), pattern, BatchOptions{Workers: 3})
+	if err != nil {
+		t.Fatalf("ExtractDirParallel出错: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("并发结果与ExtractDir不一致:\n得到 %+v\n期望 %+v", got, want)
+	}
+}
+
+func TestByteLimiter(t *testing.T) {
+	l := newByteLimiter(100)
+	ctx := context.Background()
+	if err := l.acquire(ctx, 60); err != nil {
+		t.Fatalf("acquire出错: %v", err)
+	}
+
+	// 超过上限时等待，直到超时
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(timeout, 50); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("超过上限时期望等待到超时, 得到 %v", err)
+	}
+
+	// 释放后可以继续
+	acquired := make(chan error, 1)
+	go func() { acquired <- l.acquire(ctx, 50) }()
+	l.release(60)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("释放后acquire出错: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("释放后acquire没有返回")
+	}
+
+	// 单个超过上限的输入在没有其他输入时允许处理
+	l.release(50)
+	if err := l.acquire(ctx, 1000); err != nil {
+		t.Errorf("超过上限的单个输入应被允许: %v", err)
+	}
+}
@@ -1,6 +1,7 @@
 package getcomments
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"os"
@@ -419,3 +420,40 @@ func BenchmarkExtractCommentsOptimized_RealisticCacheEfficiency(b *testing.B) {
 		}
 	}
 }
+
+// 多文件批量提取：顺序提取与并发提取
+func realisticInputs(b *testing.B) []Input {
+	prepareTestFiles(b)
+	var inputs []Input
+	for _, name := range []string{"realistic_small.go", "realistic_medium.go", "realistic_large.go", "realistic_high_comment.go"} {
+		inputs = append(inputs, FromFile(filepath.Join("testdata", name)))
+	}
+	return inputs
+}
+
+func BenchmarkExtractBatch_Sequential(b *testing.B) {
+	inputs := realisticInputs(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		for _, in := range inputs {
+			if _, err := defaultExtractor.Extract(in); err != nil {
+				b.Fatalf("提取注释失败: %v", err)
+			}
+		}
+	}
+}
+
+func BenchmarkExtractBatch_Parallel(b *testing.B) {
+	inputs := realisticInputs(b)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		err := ExtractBatch(context.Background(), inputs, BatchOptions{}, func(r BatchResult) error {
+			return r.Err
+		})
+		if err != nil {
+			b.Fatalf("提取注释失败: %v", err)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package getcomments
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// CacheValidation 缓存项的失效检测方式
+type CacheValidation int
+
+const (
+	// ValidateModTime 比较文件的修改时间和大小，开销最小
+	// 文件系统时间精度较粗时，同一时间片内大小不变的修改可能无法被发现
+	ValidateModTime CacheValidation = iota
+	// ValidateHash 每次读取文件并比较内容哈希，只节省解析开销，但结果总是准确的
+	ValidateHash
+)
+
+// CacheOptions AST缓存的配置
+type CacheOptions struct {
+	MaxEntries int             // 最多缓存的文件数，0表示不限制
+	MaxBytes   int64           // 缓存源代码的总字节数上限，0表示不限制
+	Validation CacheValidation // 失效检测方式
+}
+
+// CacheStats AST缓存的统计信息
+type CacheStats struct {
+	Hits          int64 `json:"hits"`          // 命中次数
+	Misses        int64 `json:"misses"`        // 未命中次数（包括失效后重新解析）
+	Invalidations int64 `json:"invalidations"` // 因文件变化而失效的次数
+	Evictions     int64 `json:"evictions"`     // 因容量限制被淘汰的次数
+	Entries       int   `json:"entries"`       // 当前缓存项数
+	Bytes         int64 `json:"bytes"`         // 当前缓存的源代码字节数
+}
+
+// ParsedFile 是一次解析的完整结果
+// AST中的位置只在同一个FileSet中有效，因此二者必须一起缓存和使用。
+// 缓存返回的ParsedFile会被多个调用方共享，调用方不能修改其中的AST
+type ParsedFile struct {
+	Fset    *token.FileSet
+	File    *ast.File
+	Content []byte
+	Errors  SyntaxErrors // 容错模式下的语法错误，此时File是解析器返回的部分AST；缓存中的文件总是为空
+}
+
+// ASTCache 是按文件路径索引、按LRU淘汰的AST缓存，可以被多个goroutine并发使用
+type ASTCache struct {
+	mu    sync.Mutex
+	opts  CacheOptions
+	ll    *list.List               // 最近使用的缓存项在前
+	items map[string]*list.Element // 路径 -> 缓存项
+	bytes int64
+	stats CacheStats
+}
+
+type cacheEntry struct {
+	path    string
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+	parsed  *ParsedFile
+}
+
+// NewASTCache 创建一个AST缓存
+func NewASTCache(opts CacheOptions) *ASTCache {
+	return &ASTCache{
+		opts:  opts,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// 包级默认缓存，ExtractCommentsOptimized 使用
+var defaultCache = NewASTCache(CacheOptions{MaxEntries: 1024, MaxBytes: 64 << 20})
+
+// DefaultCache 返回ExtractCommentsOptimized使用的包级缓存，可用于查看统计或清空
+func DefaultCache() *ASTCache {
+	return defaultCache
+}
+
+// Load 返回path对应文件的解析结果
+// 缓存项仍然有效时直接返回缓存，否则重新读取并解析，filename 为解析时使用的文件名
+func (c *ASTCache) Load(path, filename string) (*ParsedFile, error) {
+	key := cacheKey(path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	var content []byte
+	var hash [sha256.Size]byte
+	if c.opts.Validation == ValidateHash {
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		hash = sha256.Sum256(content)
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		if c.valid(entry, info, hash) {
+			c.ll.MoveToFront(elem)
+			c.stats.Hits++
+			c.mu.Unlock()
+			return entry.parsed, nil
+		}
+		c.removeElement(elem)
+		c.stats.Invalidations++
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// 在锁外读取和解析，避免阻塞其他文件的查询
+	if content == nil {
+		content, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		hash = sha256.Sum256(content)
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, content, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("解析代码失败: %v", err)
+	}
+
+	entry := &cacheEntry{
+		path:    key,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		hash:    hash,
+		parsed:  &ParsedFile{Fset: fset, File: f, Content: content},
+	}
+	// 读取期间文件又被修改时，以读到的内容为准，下次查询会重新校验
+	if int64(len(content)) != info.Size() {
+		entry.size = int64(len(content))
+		entry.modTime = time.Time{}
+	}
+
+	c.mu.Lock()
+	if elem, ok := c.items[key]; ok {
+		// 并发解析了同一个文件，保留最新的结果
+		c.removeElement(elem)
+	}
+	c.items[key] = c.ll.PushFront(entry)
+	c.bytes += int64(len(content))
+	c.evict()
+	c.mu.Unlock()
+
+	return entry.parsed, nil
+}
+
+// valid 判断缓存项对当前文件是否仍然有效，调用方需持有锁
+func (c *ASTCache) valid(entry *cacheEntry, info os.FileInfo, hash [sha256.Size]byte) bool {
+	if c.opts.Validation == ValidateHash {
+		return bytes.Equal(entry.hash[:], hash[:])
+	}
+	return entry.size == info.Size() && entry.modTime.Equal(info.ModTime())
+}
+
+// evict 按LRU顺序淘汰超出容量的缓存项，至少保留最新的一项，调用方需持有锁
+func (c *ASTCache) evict() {
+	for c.ll.Len() > 1 &&
+		((c.opts.MaxEntries > 0 && c.ll.Len() > c.opts.MaxEntries) ||
+			(c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes)) {
+		c.removeElement(c.ll.Back())
+		c.stats.Evictions++
+	}
+}
+
+// removeElement 删除缓存项，调用方需持有锁
+func (c *ASTCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.ll.Remove(elem)
+	delete(c.items, entry.path)
+	c.bytes -= int64(len(entry.parsed.Content))
+}
+
+// Invalidate 删除path对应的缓存项
+func (c *ASTCache) Invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[cacheKey(path)]; ok {
+		c.removeElement(elem)
+		c.stats.Invalidations++
+	}
+}
+
+// Purge 清空缓存，统计信息保留
+func (c *ASTCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// Stats 返回缓存统计信息的快照
+func (c *ASTCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.ll.Len()
+	stats.Bytes = c.bytes
+	return stats
+}
+
+// cacheKey 使用绝对路径作为缓存键，避免同一文件以不同相对路径重复缓存
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
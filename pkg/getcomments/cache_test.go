@@ -0,0 +1,157 @@
+package getcomments
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeCacheTestFile 写入测试文件并设置修改时间
+func writeCacheTestFile(t *testing.T, path, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("无法写入文件 %s: %v", path, err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("无法设置修改时间 %s: %v", path, err)
+	}
+}
+
+func TestASTCacheHitAndInvalidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeCacheTestFile(t, path, "package a\n\n// 变量x\nvar x = 1\n", modTime)
+
+	cache := NewASTCache(CacheOptions{})
+	first, err := cache.Load(path, "a.go")
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	second, err := cache.Load(path, "a.go")
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if first != second {
+		t.Errorf("第二次加载应命中缓存")
+	}
+	// 缓存的AST位置必须能用缓存的FileSet解析
+	if line := second.Fset.Position(second.File.Comments[0].Pos()).Line; line != 3 {
+		t.Errorf("缓存注释所在行为 %d, 期望 3", line)
+	}
+
+	// 修改文件后缓存应失效
+	writeCacheTestFile(t, path, "package a\n\n\n// 变量y\nvar y = 2\n", modTime.Add(time.Second))
+	third, err := cache.Load(path, "a.go")
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if third == second {
+		t.Errorf("文件修改后不应返回旧缓存")
+	}
+	if line := third.Fset.Position(third.File.Comments[0].Pos()).Line; line != 4 {
+		t.Errorf("重新解析后注释所在行为 %d, 期望 4", line)
+	}
+
+	stats := cache.Stats()
+	want := CacheStats{Hits: 1, Misses: 2, Invalidations: 1, Entries: 1, Bytes: int64(len(third.Content))}
+	if stats != want {
+		t.Errorf("统计信息为 %+v, 期望 %+v", stats, want)
+	}
+}
+
+func TestASTCacheHashValidation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.go")
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	writeCacheTestFile(t, path, "package a\n\n// 注释a\nvar a = 1\n", modTime)
+
+	modTimeCache := NewASTCache(CacheOptions{Validation: ValidateModTime})
+	hashCache := NewASTCache(CacheOptions{Validation: ValidateHash})
+	for _, cache := range []*ASTCache{modTimeCache, hashCache} {
+		if _, err := cache.Load(path, "a.go"); err != nil {
+			t.Fatalf("加载失败: %v", err)
+		}
+	}
+
+	// 大小与修改时间都不变的修改只有哈希校验能发现
+	writeCacheTestFile(t, path, "package a\n\n// 注释b\nvar b = 1\n", modTime)
+
+	stale, err := modTimeCache.Load(path, "a.go")
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if stale.File.Comments[0].Text() != "注释a\n" {
+		t.Errorf("修改时间校验应返回缓存的旧结果")
+	}
+
+	fresh, err := hashCache.Load(path, "a.go")
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if fresh.File.Comments[0].Text() != "注释b\n" {
+		t.Errorf("哈希校验应发现文件变化, 得到 %q", fresh.File.Comments[0].Text())
+	}
+	if stats := hashCache.Stats(); stats.Invalidations != 1 {
+		t.Errorf("哈希缓存的失效次数为 %d, 期望 1", stats.Invalidations)
+	}
+}
+
+func TestASTCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	paths := make([]string, 3)
+	for i, name := range []string{"a.go", "b.go", "c.go"} {
+		paths[i] = filepath.Join(dir, name)
+		writeCacheTestFile(t, paths[i], "package p\n", time.Now())
+	}
+
+	cache := NewASTCache(CacheOptions{MaxEntries: 2})
+	for _, path := range []string{paths[0], paths[1], paths[0], paths[2]} {
+		if _, err := cache.Load(path, filepath.Base(path)); err != nil {
+			t.Fatalf("加载失败: %v", err)
+		}
+	}
+	// b.go 最久未使用，应被淘汰
+	stats := cache.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("统计信息为 %+v, 期望2项且淘汰1次", stats)
+	}
+	if _, err := cache.Load(paths[0], "a.go"); err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	if hits := cache.Stats().Hits; hits != 2 {
+		t.Errorf("a.go 应仍在缓存中, 命中次数为 %d", hits)
+	}
+
+	// 按字节数限制
+	byteCache := NewASTCache(CacheOptions{MaxBytes: int64(len("package p\n")) * 2})
+	for _, path := range paths {
+		if _, err := byteCache.Load(path, filepath.Base(path)); err != nil {
+			t.Fatalf("加载失败: %v", err)
+		}
+	}
+	if stats := byteCache.Stats(); stats.Entries != 2 || stats.Bytes > int64(len("package p\n"))*2 {
+		t.Errorf("字节数限制未生效: %+v", stats)
+	}
+}
+
+func TestExtractCommentsOptimizedCached(t *testing.T) {
+	exampleFile := filepath.Join("testdata", "examples.go")
+	DefaultCache().Invalidate(exampleFile)
+
+	first, err := ExtractCommentsOptimizedFrom(FromFile(exampleFile))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	// 命中缓存时的结果必须与首次解析一致
+	second, err := ExtractCommentsOptimizedFrom(FromFile(exampleFile))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("命中缓存后的结果与首次解析不一致:\n%v\n%v", first, second)
+	}
+	if _, ok := second["examples.go:11"]; !ok {
+		t.Errorf("未找到预期的键 examples.go:11")
+	}
+}
@@ -0,0 +1,368 @@
+package getcomments
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// DocStatus 导出标识符的文档状态
+type DocStatus string
+
+const (
+	// DocPresent 有文档注释
+	DocPresent DocStatus = "documented"
+	// DocMissing 没有文档注释
+	DocMissing DocStatus = "missing"
+	// DocBadPrefix 有文档注释，但没有以标识符名称开头
+	DocBadPrefix DocStatus = "bad-prefix"
+)
+
+// DocItem 一个导出标识符的文档情况
+type DocItem struct {
+	File   string    `json:"file"`
+	Pos    Position  `json:"pos"`
+	Kind   string    `json:"kind"` // func、method、type、const、var 或 field
+	Name   string    `json:"name"` // 方法与字段带有所属类型，如 Extractor.Extract、Filter.DocOnly
+	Status DocStatus `json:"status"`
+	Doc    string    `json:"doc,omitempty"` // 文档注释的第一行
+}
+
+// CoverageStats 文档覆盖率统计，只有DocPresent计为已覆盖
+type CoverageStats struct {
+	Total      int     `json:"total"`
+	Documented int     `json:"documented"`
+	Percent    float64 `json:"percent"` // 没有导出标识符时为100
+}
+
+func (s *CoverageStats) add(status DocStatus) {
+	s.Total++
+	if status == DocPresent {
+		s.Documented++
+	}
+}
+
+func (s *CoverageStats) merge(other CoverageStats) {
+	s.Total += other.Total
+	s.Documented += other.Documented
+}
+
+func (s *CoverageStats) finish() {
+	s.Percent = 100
+	if s.Total > 0 {
+		s.Percent = float64(s.Documented) * 100 / float64(s.Total)
+	}
+}
+
+// FileCoverage 一个文件的文档覆盖率
+type FileCoverage struct {
+	File string `json:"file"`
+	CoverageStats
+	Gaps []DocItem `json:"gaps,omitempty"` // 缺少文档或文档不以名称开头的标识符
+}
+
+// PackageCoverage 一个包（目录）的文档覆盖率
+type PackageCoverage struct {
+	Package string `json:"package"`
+	CoverageStats
+	Files []FileCoverage `json:"files"`
+}
+
+// CoverageReport 模块的文档覆盖率报告
+type CoverageReport struct {
+	CoverageStats
+	Packages []PackageCoverage `json:"packages"`
+	Skipped  []string          `json:"skipped,omitempty"` // 按generated.Skip跳过的生成文件
+	Errors   []FileError       `json:"errors,omitempty"`
+}
+
+// DocCoverage 统计输入中导出标识符的文档覆盖率
+func DocCoverage(in Input) (*FileCoverage, error) {
+	return defaultExtractor.DocCoverage(in)
+}
+
+// DocCoverageDir 按目录模式统计文档覆盖率，pattern 的规则与ExtractDir相同，_test.go 文件不参与统计
+func DocCoverageDir(pattern string) (*CoverageReport, error) {
+	return defaultExtractor.DocCoverageDir(pattern)
+}
+
+// DocCoverage 使用提取器的配置统计输入中导出标识符的文档覆盖率
+// 标识符来自注释关联的声明处理过程（processDeclarations），与提取结果访问相同的声明与字段，
+// 包括匿名结构体、指针、切片与映射元素类型中的字段；类型参数与类型集合元素不参与统计。
+// 是否有文档按Go文档注释的规则判断，而不是按关联到声明行的注释：函数使用FuncDecl.Doc，
+// 常量、变量与类型使用规格自身或所在声明的Doc；结构体字段与接口方法的文档注释或行尾注释都视为文档。
+// 函数、方法、类型以及单独声明的常量、变量的文档必须以名称开头（允许 A、An、The 前缀），否则记为DocBadPrefix。
+// 按generated.Skip跳过的生成文件返回没有标识符的结果
+func (e *Extractor) DocCoverage(in Input) (*FileCoverage, error) {
+	fc, _, err := e.docCoverage(in)
+	return fc, err
+}
+
+// docCoverage 统计输入的文档覆盖率，skip 表示输入是被跳过的生成文件
+func (e *Extractor) docCoverage(in Input) (*FileCoverage, bool, error) {
+	c := &coverage{result: &FileCoverage{File: in.Filename()}}
+	parsed, skip, err := e.parseChecked(in)
+	if err != nil {
+		return nil, false, err
+	}
+	if !skip {
+		a, release := e.newAssociation(in.Filename(), parsed)
+		defer release()
+		c.fset = parsed.Fset
+		a.decls = c
+		a.processDeclarations()
+	}
+	c.result.finish()
+	return c.result, skip, nil
+}
+
+// DocCoverageDir 使用提取器的配置按目录模式统计文档覆盖率，跳过的生成文件记录在Skipped中，
+// 拒绝处理生成文件时返回错误
+func (e *Extractor) DocCoverageDir(pattern string) (*CoverageReport, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var files []FileCoverage
+	var errs []FileError
+	var skipped []string
+	for _, in := range dir.Inputs {
+		if strings.HasSuffix(in.Filename(), "_test.go") {
+			continue
+		}
+		fc, skip, err := e.docCoverage(in)
+		switch {
+		case errors.Is(err, generated.ErrGenerated):
+			return nil, err
+		case err != nil:
+			errs = append(errs, FileError{File: in.Filename(), Err: err.Error()})
+			continue
+		case skip:
+			skipped = append(skipped, in.Filename())
+			continue
+		}
+		files = append(files, *fc)
+	}
+	report := NewCoverageReport(files)
+	report.Skipped = skipped
+	report.Errors = append(dir.Errors, errs...)
+	return report, nil
+}
+
+// NewCoverageReport 按文件所在目录汇总文件覆盖率
+func NewCoverageReport(files []FileCoverage) *CoverageReport {
+	report := &CoverageReport{}
+	index := make(map[string]int)
+	for _, fc := range files {
+		pkg := path.Dir(fc.File)
+		i, ok := index[pkg]
+		if !ok {
+			i = len(report.Packages)
+			index[pkg] = i
+			report.Packages = append(report.Packages, PackageCoverage{Package: pkg})
+		}
+		report.Packages[i].Files = append(report.Packages[i].Files, fc)
+		report.Packages[i].merge(fc.CoverageStats)
+		report.merge(fc.CoverageStats)
+	}
+	for i := range report.Packages {
+		report.Packages[i].finish()
+	}
+	sort.Slice(report.Packages, func(i, j int) bool {
+		return report.Packages[i].Package < report.Packages[j].Package
+	})
+	report.finish()
+	return report
+}
+
+// Gaps 返回报告中所有缺少文档或文档不以名称开头的标识符
+func (r *CoverageReport) Gaps() []DocItem {
+	var gaps []DocItem
+	for _, pkg := range r.Packages {
+		for _, fc := range pkg.Files {
+			gaps = append(gaps, fc.Gaps...)
+		}
+	}
+	return gaps
+}
+
+// coverage 统计一个文件的文档覆盖率，作为declVisitor接收声明处理过程访问到的声明与字段
+type coverage struct {
+	fset   *token.FileSet
+	result *FileCoverage
+	owner  string    // 当前字段所属的类型或变量名，为空时不统计字段（如函数的类型参数）
+	body   token.Pos // 当前类型或变量的类型表达式起始位置，之前的字段是类型参数
+}
+
+// funcDecl 统计导出的函数与导出类型上的导出方法
+func (c *coverage) funcDecl(funcDecl *ast.FuncDecl) {
+	c.owner = ""
+	if !funcDecl.Name.IsExported() {
+		return
+	}
+	kind, name := "func", funcDecl.Name.Name
+	if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
+		recv := receiverTypeName(funcDecl.Recv.List[0].Type)
+		if !ast.IsExported(recv) {
+			return
+		}
+		kind, name = "method", recv+"."+name
+	}
+	c.record(funcDecl.Name, kind, name, funcDecl.Doc, nil, true)
+}
+
+// spec 统计导出的常量、变量与类型，并记录随后访问的字段所属的名称
+func (c *coverage) spec(genDecl *ast.GenDecl, spec ast.Spec) {
+	c.owner = ""
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		c.owner, c.body = s.Name.Name, s.Type.Pos()
+		if !s.Name.IsExported() {
+			return
+		}
+		doc := s.Doc
+		if doc == nil && !genDecl.Lparen.IsValid() {
+			doc = genDecl.Doc
+		}
+		c.record(s.Name, "type", s.Name.Name, doc, nil, true)
+	case *ast.ValueSpec:
+		if s.Type != nil && len(s.Names) > 0 {
+			c.owner, c.body = s.Names[0].Name, s.Type.Pos()
+		}
+		// 分组声明中的规格可以共用声明的文档，此时不检查名称前缀
+		doc, checkPrefix := s.Doc, true
+		if doc == nil {
+			doc, checkPrefix = genDecl.Doc, !genDecl.Lparen.IsValid()
+		}
+		for _, ident := range s.Names {
+			if ident.IsExported() {
+				c.record(ident, genDecl.Tok.String(), ident.Name, doc, nil, checkPrefix && len(s.Names) == 1)
+			}
+		}
+	}
+}
+
+// field 统计导出类型或变量中路径上各级名称都导出的字段与接口方法；
+// 接口中嵌入的接口或类型约束不是方法，不参与统计
+func (c *coverage) field(field *ast.Field, named, owner OwnerKind, path string) {
+	if c.owner == "" || field.Pos() < c.body {
+		return
+	}
+	kind := "field"
+	switch owner {
+	case OwnerMethod:
+		kind = "method"
+	case OwnerEmbedded:
+		if named == OwnerMethod {
+			return
+		}
+	case OwnerTypeSet, OwnerTypeParam:
+		return
+	}
+	// path 以字段的第一个名称结尾，同一行声明的多个名称分别统计
+	names := field.Names
+	if len(names) == 0 {
+		names = []*ast.Ident{embeddedFieldIdent(field.Type)}
+	}
+	parent := c.owner + "." + strings.TrimSuffix(path, names[0].Name)
+	for _, part := range strings.Split(strings.TrimSuffix(parent, "."), ".") {
+		if !ast.IsExported(part) {
+			return
+		}
+	}
+	for _, ident := range names {
+		if ident.IsExported() {
+			c.record(ident, kind, parent+ident.Name, field.Doc, field.Comment, false)
+		}
+	}
+}
+
+// record 记录一个导出标识符，trailing 为字段的行尾注释
+func (c *coverage) record(ident *ast.Ident, kind, name string, doc, trailing *ast.CommentGroup, checkPrefix bool) {
+	pos := c.fset.Position(ident.Pos())
+	item := DocItem{
+		File:   c.result.File,
+		Pos:    Position{Line: pos.Line, Column: pos.Column},
+		Kind:   kind,
+		Name:   name,
+		Status: DocPresent,
+	}
+	if doc == nil {
+		doc = trailing
+	}
+	text := ""
+	if doc != nil {
+		text = strings.TrimSpace(doc.Text())
+	}
+	if first, _, _ := strings.Cut(text, "\n"); first != "" {
+		item.Doc = first
+	}
+	switch {
+	case text == "":
+		item.Status = DocMissing
+	case checkPrefix && !docStartsWithName(text, ident.Name):
+		item.Status = DocBadPrefix
+	}
+	c.result.add(item.Status)
+	if item.Status != DocPresent {
+		c.result.Gaps = append(c.result.Gaps, item)
+	}
+}
+
+// docStartsWithName 判断文档是否以名称开头，允许 A、An、The 前缀
+func docStartsWithName(text, name string) bool {
+	for _, article := range []string{"", "A ", "An ", "The "} {
+		rest, ok := strings.CutPrefix(text, article+name)
+		if !ok {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(rest); rest == "" || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return true
+		}
+	}
+	return false
+}
+
+// receiverTypeName 返回接收者的类型名，省略指针与类型参数
+func receiverTypeName(expr ast.Expr) string {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+	if ident, ok := expr.(*ast.Ident); ok {
+		return ident.Name
+	}
+	return ""
+}
+
+// embeddedFieldIdent 返回嵌入字段的类型名，如 *pkg.T 中的 T
+func embeddedFieldIdent(expr ast.Expr) *ast.Ident {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		expr = e.X
+	case *ast.IndexListExpr:
+		expr = e.X
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package getcomments
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+const coverageTestCode = `package p
+
+// Server 服务
+type Server struct {
+	Addr string // 监听地址
+	// Timeout 超时时间
+	Timeout int
+	Name    string
+	Options struct {
+		Debug bool
+	}
+	*Logger
+	internal int
+}
+
+// 启动服务
+func (s *Server) Start() {}
+
+// Stop 停止服务
+func (s *Server) Stop() {}
+
+func (s *Server) Restart() {}
+
+func (s *server) Hidden() {}
+
+// Handler 处理请求
+type Handler interface {
+	Serve() // 处理
+	Close()
+}
+
+// 常量分组
+const (
+	A = 1
+	// B 说明
+	B = 2
+)
+
+var Version = "1.0"
+
+// A Config holds settings.
+type Config struct{}
+
+func helper() {}
+
+// Options 选项
+var Options struct {
+	Verbose bool
+	Hooks   []struct {
+		Name string
+	}
+}
+
+// Map 泛型映射
+type Map[K interface{ Key() string }, V any] struct {
+	X, Y  int // 坐标
+	Items map[K]*struct {
+		Value V
+	}
+}
+`
+
+func TestDocCoverage(t *testing.T) {
+	fc, err := DocCoverage(FromSource("p.go", []byte(coverageTestCode)))
+	if err != nil {
+		t.Fatalf("统计文档覆盖率失败: %v", err)
+	}
+
+	type gap struct {
+		line   int
+		kind   string
+		name   string
+		status DocStatus
+	}
+	want := []gap{
+		{8, "field", "Server.Name", DocMissing},
+		{9, "field", "Server.Options", DocMissing},
+		{10, "field", "Server.Options.Debug", DocMissing},
+		{12, "field", "Server.Logger", DocMissing},
+		{17, "method", "Server.Start", DocBadPrefix},
+		{22, "method", "Server.Restart", DocMissing},
+		{29, "method", "Handler.Close", DocMissing},
+		{39, "var", "Version", DocMissing},
+		// 变量的匿名结构体以及切片、映射元素类型中的字段，与注释提取访问的字段相同
+		{48, "field", "Options.Verbose", DocMissing},
+		{49, "field", "Options.Hooks", DocMissing},
+		{50, "field", "Options.Hooks.Name", DocMissing},
+		{57, "field", "Map.Items", DocMissing},
+		{58, "field", "Map.Items.Value", DocMissing},
+	}
+	var got []gap
+	for _, item := range fc.Gaps {
+		got = append(got, gap{item.Pos.Line, item.Kind, item.Name, item.Status})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("缺少文档的标识符为\n%v\n期望\n%v", got, want)
+	}
+
+	// Server、Addr、Timeout、Stop、Handler、Serve、A、B、Config、Options、Map、Map.X、Map.Y 有文档，
+	// 类型参数约束中的Key不参与统计
+	if fc.Total != 26 || fc.Documented != 13 {
+		t.Errorf("统计为 %d/%d, 期望 13/26", fc.Documented, fc.Total)
+	}
+}
+
+func TestNewCoverageReport(t *testing.T) {
+	report := NewCoverageReport([]FileCoverage{
+		{File: "b/x.go", CoverageStats: CoverageStats{Total: 4, Documented: 1}},
+		{File: "a/y.go", CoverageStats: CoverageStats{Total: 0}},
+		{File: "b/z.go", CoverageStats: CoverageStats{Total: 4, Documented: 4}},
+	})
+	if report.Total != 8 || report.Documented != 5 || report.Percent != 62.5 {
+		t.Errorf("模块统计为 %+v", report.CoverageStats)
+	}
+	if len(report.Packages) != 2 || report.Packages[0].Package != "a" || report.Packages[0].Percent != 100 ||
+		report.Packages[1].Package != "b" || report.Packages[1].Percent != 62.5 {
+		t.Errorf("包统计为 %+v", report.Packages)
+	}
+}
+
+func TestDocCoverageDirSkipsGenerated(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":    "package p\n\n// A 说明\nfunc A() {}\n",
+		"a.pb.go": "// Code generated by protoc. DO NOT EDIT.\n\npackage p\n\nfunc B() {}\n\ntype C struct{ D int }\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", name, err)
+		}
+	}
+
+	report, err := NewExtractor(WithGenerated(generated.Skip)).DocCoverageDir(root)
+	if err != nil {
+		t.Fatalf("统计文档覆盖率失败: %v", err)
+	}
+	if report.Total != 1 || report.Documented != 1 {
+		t.Errorf("统计为 %d/%d, 期望 1/1", report.Documented, report.Total)
+	}
+	if !reflect.DeepEqual(report.Skipped, []string{"a.pb.go"}) {
+		t.Errorf("跳过的文件为 %v，期望 [a.pb.go]", report.Skipped)
+	}
+
+	// 默认照常统计生成文件
+	if report, err = DocCoverageDir(root); err != nil || report.Total != 4 {
+		t.Errorf("默认应统计生成文件中的标识符: %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,346 @@
+package getcomments
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strings"
+)
+
+// DeadCode 一段被注释掉的代码
+type DeadCode struct {
+	File       string   `json:"file"`
+	Start      Position `json:"start"`
+	End        Position `json:"end"`
+	Lines      int      `json:"lines"`
+	Kind       string   `json:"kind"`       // decl、stmt 或 expr
+	Confidence float64  `json:"confidence"` // 0到1之间，越大越可能是代码
+	Code       string   `json:"code"`       // 去除注释符号后的代码
+}
+
+// maxDeadCodeLines 一段注释代码的最大行数，限制逐行扩展时的解析次数
+const maxDeadCodeLines = 50
+
+// FindDeadCode 查找输入中被注释掉的代码
+func FindDeadCode(in Input) ([]DeadCode, error) {
+	return defaultExtractor.FindDeadCode(in)
+}
+
+// RemoveDeadCode 删除输入中置信度不低于minConfidence的注释代码
+func RemoveDeadCode(in Input, minConfidence float64) (*ApplyResult, error) {
+	return defaultExtractor.RemoveDeadCode(in, minConfidence)
+}
+
+// FindDeadCode 使用提取器的配置查找被注释掉的代码
+// 注释组中连续的若干行能够被go/parser解析为声明、语句或表达式时视为一段代码，每行尽量向后扩展到最长的可解析范围。
+// 只有标识符或字面量的行（如 "提取注释"、"TODO"）不是代码；文档注释中缩进的示例代码、编译器指令以及行尾注释之后的行分别处理。
+// 置信度按代码的种类计算：声明、赋值与控制语句最高，函数调用次之，其他表达式与标签最低；
+// 多行代码的置信度更高，包含非ASCII标识符（多为中文说明恰好能够解析）时降低到原来的十分之一，低于0.1时不视为代码
+func (e *Extractor) FindDeadCode(in Input) ([]DeadCode, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	var result []DeadCode
+	for _, run := range findDeadCode(in.Filename(), parsed) {
+		result = append(result, run.DeadCode)
+	}
+	return result, nil
+}
+
+// RemoveDeadCode 使用提取器的配置删除注释代码，整行的注释连同所在行一起删除，
+// 删除后前后都是空行时只保留一个空行，文件末尾不留空行
+func (e *Extractor) RemoveDeadCode(in Input, minConfidence float64) (*ApplyResult, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	var edits []commentEdit
+	for _, run := range findDeadCode(in.Filename(), parsed) {
+		if run.Confidence >= minConfidence {
+			edits = append(edits, run.deletion(parsed))
+		}
+	}
+	result := &ApplyResult{}
+	if err := result.apply(parsed, edits); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// deadCodeRun 一段注释代码及其对应的注释
+type deadCodeRun struct {
+	DeadCode
+	comments []*ast.Comment
+}
+
+// codeLine 注释中的一行
+type codeLine struct {
+	comment *ast.Comment
+	text    string
+}
+
+func findDeadCode(filename string, parsed *ParsedFile) []deadCodeRun {
+	docs := collectDocComments(parsed.File)
+	var runs []deadCodeRun
+	for _, cg := range parsed.File.Comments {
+		var segment []codeLine
+		flush := func() {
+			runs = append(runs, scanDeadCode(filename, parsed.Fset, segment)...)
+			segment = nil
+		}
+		for _, c := range cg.List {
+			switch {
+			case IsDirective(c.Text):
+				flush()
+			case strings.HasPrefix(c.Text, "/*"):
+				// 块注释整体作为一段
+				flush()
+				segment = []codeLine{{c, strings.TrimSuffix(c.Text[2:], "*/")}}
+				flush()
+			default:
+				text := c.Text[2:]
+				if docs[c] && (strings.HasPrefix(text, "\t") || strings.HasPrefix(text, "  ")) {
+					// 文档中的示例代码
+					flush()
+					continue
+				}
+				if !isLineStart(parsed, c) {
+					// 行尾注释与后面的整行注释分开处理
+					flush()
+					segment = []codeLine{{c, strings.TrimPrefix(text, " ")}}
+					flush()
+					continue
+				}
+				segment = append(segment, codeLine{c, strings.TrimPrefix(text, " ")})
+			}
+		}
+		flush()
+	}
+	return runs
+}
+
+// scanDeadCode 从每一行开始查找最长的可解析范围
+func scanDeadCode(filename string, fset *token.FileSet, lines []codeLine) []deadCodeRun {
+	var runs []deadCodeRun
+	for i := 0; i < len(lines); {
+		if strings.TrimSpace(lines[i].text) == "" {
+			i++
+			continue
+		}
+		found := false
+		for j := min(len(lines), i+maxDeadCodeLines); j > i; j-- {
+			if strings.TrimSpace(lines[j-1].text) == "" {
+				continue
+			}
+			texts := make([]string, 0, j-i)
+			for _, line := range lines[i:j] {
+				texts = append(texts, line.text)
+			}
+			code := strings.Join(texts, "\n")
+			kind, confidence := classifyCode(code)
+			if kind == "" {
+				continue
+			}
+			start := fset.Position(lines[i].comment.Pos())
+			end := fset.Position(lines[j-1].comment.End())
+			run := deadCodeRun{
+				DeadCode: DeadCode{
+					File:       filename,
+					Start:      Position{Line: start.Line, Column: start.Column},
+					End:        Position{Line: end.Line, Column: end.Column},
+					Lines:      end.Line - start.Line + 1,
+					Kind:       kind,
+					Confidence: confidence,
+					Code:       code,
+				},
+			}
+			for _, line := range lines[i:j] {
+				if len(run.comments) == 0 || run.comments[len(run.comments)-1] != line.comment {
+					run.comments = append(run.comments, line.comment)
+				}
+			}
+			runs = append(runs, run)
+			i, found = j, true
+			break
+		}
+		if !found {
+			i++
+		}
+	}
+	return runs
+}
+
+// classifyCode 判断文本能否解析为Go代码，返回代码的种类与置信度，不是代码时种类为空
+func classifyCode(code string) (string, float64) {
+	fset := token.NewFileSet()
+	var kind string
+	var score float64
+	var node ast.Node
+	if f, err := parser.ParseFile(fset, "", "package p\n"+code, 0); err == nil && len(f.Decls) > 0 {
+		kind, score, node = "decl", 0.9, f
+	} else if f, err := parser.ParseFile(fset, "", "package p\nfunc _() {\n"+code+"\n}", 0); err == nil {
+		body := f.Decls[0].(*ast.FuncDecl).Body
+		// 全部是非调用的表达式时种类为expr
+		kind, score = "expr", 0
+		for _, stmt := range body.List {
+			s := stmtScore(stmt)
+			if s == 0 {
+				// 只有标识符或字面量的行是普通文字
+				return "", 0
+			}
+			score = max(score, s)
+			if expr, ok := stmt.(*ast.ExprStmt); !ok || isCall(expr.X) {
+				kind = "stmt"
+			}
+		}
+		if score == 0 {
+			return "", 0
+		}
+		node = body
+	} else if expr, err := parser.ParseExpr(code); err == nil && !isTrivialExpr(expr) {
+		kind, score, node = "expr", 0.4, expr
+	} else {
+		return "", 0
+	}
+
+	if strings.Contains(strings.TrimSpace(code), "\n") {
+		score = math.Min(1, score+0.1)
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && !isASCII(ident.Name) {
+			score *= 0.1
+			return false
+		}
+		return true
+	})
+	score = math.Round(score*100) / 100
+	if score < 0.1 {
+		return "", 0
+	}
+	return kind, score
+}
+
+// stmtScore 单条语句的置信度，只有标识符或字面量时为0，空语句为-1
+func stmtScore(stmt ast.Stmt) float64 {
+	switch s := stmt.(type) {
+	case *ast.EmptyStmt:
+		return -1
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			if _, ok := call.Fun.(*ast.SelectorExpr); ok {
+				return 0.8
+			}
+			return 0.7
+		}
+		if isTrivialExpr(s.X) {
+			return 0
+		}
+		return 0.4
+	case *ast.LabeledStmt:
+		// "注意: 说明" 形式的文字可以解析为标签
+		return min(0.2, stmtScore(s.Stmt))
+	case *ast.ReturnStmt:
+		if len(s.Results) > 0 {
+			return 0.8
+		}
+		return 0.4
+	case *ast.BranchStmt:
+		return 0.4
+	}
+	return 0.9
+}
+
+func isCall(expr ast.Expr) bool {
+	_, ok := expr.(*ast.CallExpr)
+	return ok
+}
+
+// isTrivialExpr 判断表达式是否只是一个标识符或字面量
+func isTrivialExpr(expr ast.Expr) bool {
+	switch expr.(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return true
+	}
+	return false
+}
+
+// isLineStart 判断注释之前是否只有空白
+func isLineStart(parsed *ParsedFile, c *ast.Comment) bool {
+	file := parsed.Fset.File(c.Pos())
+	offset := file.Offset(c.Pos())
+	lineStart := file.Offset(file.LineStart(file.Line(c.Pos())))
+	return strings.TrimSpace(string(parsed.Content[lineStart:offset])) == ""
+}
+
+// isLineEnd 判断注释之后是否只有空白
+func isLineEnd(parsed *ParsedFile, c *ast.Comment) bool {
+	file := parsed.Fset.File(c.Pos())
+	rest := parsed.Content[file.Offset(c.End()):]
+	return strings.TrimSpace(string(rest[:lineLength(rest)])) == ""
+}
+
+// deletion 返回删除这段注释代码的编辑
+func (r *deadCodeRun) deletion(parsed *ParsedFile) commentEdit {
+	first, last := r.comments[0], r.comments[len(r.comments)-1]
+	file := parsed.Fset.File(first.Pos())
+	content := parsed.Content
+	start, end := file.Offset(first.Pos()), file.Offset(last.End())
+
+	if !isLineStart(parsed, first) {
+		// 行尾注释：连同前面的空白一起删除
+		for start > 0 && (content[start-1] == ' ' || content[start-1] == '\t') {
+			start--
+		}
+		return commentEdit{start: start, end: end}
+	}
+	if !isLineEnd(parsed, last) {
+		// 块注释后面同一行还有代码（如 "/* f(1) */ f(2)"）：只删除注释与其后的空白
+		for end < len(content) && (content[end] == ' ' || content[end] == '\t') {
+			end++
+		}
+		return commentEdit{start: start, end: end}
+	}
+
+	startLine, endLine := file.Line(first.Pos()), file.Line(last.End())
+	start = file.Offset(file.LineStart(startLine))
+	end = len(content)
+	if endLine < file.LineCount() {
+		end = file.Offset(file.LineStart(endLine + 1))
+	}
+	if start > 0 && blankLineBefore(content, start) {
+		switch {
+		case blankLineAt(content, end):
+			// 删除后前后都是空行，去掉其中一个
+			end += lineLength(content[end:])
+		case end == len(content):
+			// 删除后文件以空行结尾，去掉该空行
+			start = strings.LastIndexByte(string(content[:start-1]), '\n') + 1
+		}
+	}
+	return commentEdit{start: start, end: end}
+}
+
+// blankLineBefore 判断offset所在行的上一行是否为空行
+func blankLineBefore(content []byte, offset int) bool {
+	prev := strings.LastIndexByte(string(content[:offset-1]), '\n')
+	return strings.TrimSpace(string(content[prev+1:offset])) == ""
+}
+
+// blankLineAt 判断从offset开始的一行是否为空行，文件末尾不算空行
+func blankLineAt(content []byte, offset int) bool {
+	if offset >= len(content) {
+		return false
+	}
+	n := lineLength(content[offset:])
+	return strings.TrimSpace(string(content[offset:offset+n])) == ""
+}
+
+// lineLength 返回第一行包括换行符的长度
+func lineLength(content []byte) int {
+	if i := strings.IndexByte(string(content), '\n'); i >= 0 {
+		return i + 1
+	}
+	return len(content)
+}
@@ -0,0 +1,127 @@
+package getcomments
+
+import (
+	"reflect"
+	"testing"
+)
+
+const deadCodeTestCode = `package p
+
+import "fmt"
+
+// Run 运行任务
+//
+//	Run()
+func Run() {
+	// 提取注释
+	// result, err := extract(input)
+
+	x := 1 // x++
+	// if x > 0 {
+	// 	fmt.Println(x)
+	// }
+
+	// 注意: 说明
+	// TODO
+	fmt.Println(x)
+	/* return x */
+}
+
+// func old() {}
+`
+
+const deadCodeFixedCode = `package p
+
+import "fmt"
+
+// Run 运行任务
+//
+//	Run()
+func Run() {
+	// 提取注释
+
+	x := 1
+
+	// 注意: 说明
+	// TODO
+	fmt.Println(x)
+}
+`
+
+func TestFindDeadCode(t *testing.T) {
+	found, err := FindDeadCode(FromSource("p.go", []byte(deadCodeTestCode)))
+	if err != nil {
+		t.Fatalf("查找注释代码失败: %v", err)
+	}
+
+	type dead struct {
+		line       int
+		lines      int
+		kind       string
+		confidence float64
+	}
+	want := []dead{
+		{10, 1, "stmt", 0.9},
+		{12, 1, "stmt", 0.9},
+		{13, 3, "stmt", 1},
+		{20, 1, "stmt", 0.8},
+		{23, 1, "decl", 0.9},
+	}
+	var got []dead
+	for _, d := range found {
+		got = append(got, dead{d.Start.Line, d.Lines, d.Kind, d.Confidence})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("注释代码为\n%v\n期望\n%v", got, want)
+	}
+	if found[2].Code != "if x > 0 {\n\tfmt.Println(x)\n}" {
+		t.Errorf("代码为 %q", found[2].Code)
+	}
+}
+
+func TestRemoveDeadCode(t *testing.T) {
+	result, err := RemoveDeadCode(FromSource("p.go", []byte(deadCodeTestCode)), 0.5)
+	if err != nil {
+		t.Fatalf("删除注释代码失败: %v", err)
+	}
+	if result.Applied != 5 {
+		t.Errorf("删除了 %d 段代码, 期望 5", result.Applied)
+	}
+	if string(result.Source) != deadCodeFixedCode {
+		t.Errorf("删除后的代码为\n%s\n期望\n%s", result.Source, deadCodeFixedCode)
+	}
+}
+
+// TestRemoveDeadCodeKeepsCodeAfterBlock 块注释后面同一行的代码不能随注释一起删除
+func TestRemoveDeadCodeKeepsCodeAfterBlock(t *testing.T) {
+	code := "package p\n\nimport \"fmt\"\n\nfunc f() {\n\t/* fmt.Println(1) */ fmt.Println(2)\n}\n"
+	result, err := RemoveDeadCode(FromSource("p.go", []byte(code)), 0.5)
+	if err != nil {
+		t.Fatalf("删除注释代码失败: %v", err)
+	}
+	want := "package p\n\nimport \"fmt\"\n\nfunc f() {\n\tfmt.Println(2)\n}\n"
+	if result.Applied != 1 || string(result.Source) != want {
+		t.Errorf("删除后的代码为\n%s\n期望\n%s", result.Source, want)
+	}
+}
+
+func TestClassifyCode(t *testing.T) {
+	tests := []struct {
+		code string
+		kind string
+	}{
+		{"fmt.Println(mainPackages)", "stmt"},
+		{"type T struct{}", "decl"},
+		{"a + b", "expr"},
+		{"TODO", ""},
+		{"https://example.com", ""},
+		{"see the docs", ""},
+		{"提取注释", ""},
+		{"返回(结果)", ""},
+	}
+	for _, tt := range tests {
+		if kind, _ := classifyCode(tt.code); kind != tt.kind {
+			t.Errorf("classifyCode(%q) = %q, 期望 %q", tt.code, kind, tt.kind)
+		}
+	}
+}
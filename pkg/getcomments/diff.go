@@ -0,0 +1,323 @@
+package getcomments
+
+import (
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"hash/fnv"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// ChangeKind 注释变更的类型
+type ChangeKind string
+
+const (
+	// ChangeAdded 新增的注释
+	ChangeAdded ChangeKind = "added"
+	// ChangeRemoved 删除的注释
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeEdited 同一符号下内容被修改的注释
+	ChangeEdited ChangeKind = "edited"
+	// ChangeMoved 内容不变、移到其他符号下的注释
+	ChangeMoved ChangeKind = "moved"
+	// ChangeStale 所属符号的代码已修改，注释却没有变化
+	ChangeStale ChangeKind = "stale"
+)
+
+// ChangeKinds 所有变更类型，按报告中的顺序排列
+var ChangeKinds = []ChangeKind{ChangeAdded, ChangeRemoved, ChangeEdited, ChangeMoved, ChangeStale}
+
+// CommentChange 一条注释的变更
+type CommentChange struct {
+	Kind      ChangeKind     `json:"kind"`
+	Symbol    string         `json:"symbol"`              // 注释在新版本中所属的符号，删除的注释为旧版本中的符号
+	OldSymbol string         `json:"oldSymbol,omitempty"` // 移动前所属的符号
+	Old       *CommentRecord `json:"old,omitempty"`       // 旧版本中的注释，新增时为空
+	New       *CommentRecord `json:"new,omitempty"`       // 新版本中的注释，删除时为空
+}
+
+// CommentDiff 两个版本之间的注释变更
+type CommentDiff struct {
+	Old     string          `json:"old"`
+	New     string          `json:"new"`
+	Changes []CommentChange `json:"changes"`           // 按符号排列
+	Errors  []FileError     `json:"errors,omitempty"`  // 两个版本中无法提取的文件
+	Skipped []string        `json:"skipped,omitempty"` // 按generated.Skip跳过的生成文件
+}
+
+// Count 返回指定类型的变更数量
+func (d *CommentDiff) Count(kind ChangeKind) int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// DiffComments 比较两个文件或两个目录的注释，目录的规则与ExtractDir相同
+func DiffComments(oldPath, newPath string) (*CommentDiff, error) {
+	return defaultExtractor.DiffComments(oldPath, newPath)
+}
+
+// DiffCommentsFrom 比较两个输入的注释
+func DiffCommentsFrom(oldIn, newIn Input) (*CommentDiff, error) {
+	return defaultExtractor.DiffCommentsFrom(oldIn, newIn)
+}
+
+// DiffComments 使用提取器的配置比较两个文件或两个目录的注释
+// 目录中的注释按"相对目录/符号"配对，因此同一个包内在文件之间移动的声明不会被视为变更
+func (e *Extractor) DiffComments(oldPath, newPath string) (*CommentDiff, error) {
+	oldDir, newDir := IsDirPattern(oldPath), IsDirPattern(newPath)
+	if oldDir != newDir {
+		return nil, fmt.Errorf("不能比较文件与目录: %s, %s", oldPath, newPath)
+	}
+	if !oldDir {
+		return e.DiffCommentsFrom(FromFile(oldPath), FromFile(newPath))
+	}
+
+	diff := &CommentDiff{Old: oldPath, New: newPath}
+	oldComments, err := e.diffDir(oldPath, diff)
+	if err != nil {
+		return nil, err
+	}
+	newComments, err := e.diffDir(newPath, diff)
+	if err != nil {
+		return nil, err
+	}
+	diff.Changes = diffChanges(oldComments, newComments)
+	return diff, nil
+}
+
+// DiffCommentsFrom 使用提取器的配置比较两个输入的注释
+// 注释按所属符号配对，而不是按行号，算法如下：
+//  1. 符号、注释类型与文本都相同的注释视为未变；语句序号不同但位于同一声明中的也视为未变，
+//     这样在函数中插入语句不会让后面的注释都变成移动
+//  2. 未变的注释所属符号的代码（忽略注释与空白）不同时报告为stale
+//  3. 文本相同但属于其他声明的注释报告为moved
+//  4. 同一符号与类型下剩余的注释按顺序配对，报告为edited
+//  5. 其余注释报告为added或removed
+func (e *Extractor) DiffCommentsFrom(oldIn, newIn Input) (*CommentDiff, error) {
+	diff := &CommentDiff{Old: oldIn.Filename(), New: newIn.Filename()}
+	oldComments, err := e.diffInput(oldIn, "", diff)
+	if err != nil {
+		return nil, err
+	}
+	newComments, err := e.diffInput(newIn, "", diff)
+	if err != nil {
+		return nil, err
+	}
+	diff.Changes = diffChanges(oldComments, newComments)
+	return diff, nil
+}
+
+// diffComment 参与比较的一条注释
+type diffComment struct {
+	record  CommentRecord
+	key     string // 配对使用的符号键
+	decl    string // 去掉语句序号后的符号键，即所在的声明
+	code    uint64 // 所属符号代码的指纹，属于包时为0
+	matched bool
+}
+
+// diffDir 提取目录中每个文件的注释，符号键以相对于pattern目录的路径为前缀，
+// 使不同位置的两个版本（如两个工作区）可以配对；单个文件的错误收集到diff.Errors中
+func (e *Extractor) diffDir(pattern string, diff *CommentDiff) ([]*diffComment, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	base, _ := splitDirPattern(pattern)
+	if base, err = filepath.Abs(base); err != nil {
+		return nil, fmt.Errorf("获取绝对路径失败: %v", err)
+	}
+
+	diff.Errors = append(diff.Errors, dir.Errors...)
+	var comments []*diffComment
+	for _, in := range dir.Inputs {
+		prefix := path.Dir(relativePath(base, in.Path()))
+		fileComments, err := e.diffInput(in, prefix, diff)
+		if err != nil {
+			diff.Errors = append(diff.Errors, FileError{File: in.Filename(), Err: err.Error()})
+			continue
+		}
+		comments = append(comments, fileComments...)
+	}
+	return comments, nil
+}
+
+// diffInput 提取输入的注释以及每条注释所属符号的代码指纹，prefix 不为空且不为"."时作为符号键的前缀；
+// 跳过的生成文件记录在diff.Skipped中
+func (e *Extractor) diffInput(in Input, prefix string, diff *CommentDiff) ([]*diffComment, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	if skip, err := e.gen.Check(in.Filename(), generated.IsGenerated(parsed.File)); skip || err != nil {
+		if skip {
+			diff.Skipped = append(diff.Skipped, in.Filename())
+		}
+		return nil, err
+	}
+
+	builder := e.associateBuilder(in.Filename(), parsed)
+	fingerprints := make(map[ast.Node]uint64)
+	comments := make([]*diffComment, 0, len(builder.records))
+	for i, r := range builder.records {
+		key := r.Symbol
+		if prefix != "" && prefix != "." {
+			key = prefix + "/" + key
+		}
+		decl, _, _ := strings.Cut(key, "#stmt")
+		c := &diffComment{record: r, key: key, decl: decl}
+		if owner := builder.owners[i]; owner != nil {
+			code, ok := fingerprints[owner]
+			if !ok {
+				code = codeFingerprint(parsed, owner)
+				fingerprints[owner] = code
+			}
+			c.code = code
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+// codeFingerprint 计算节点代码的指纹，只使用记号，忽略注释、空白与自动插入的分号，
+// 因此重新格式化或只修改注释不会改变指纹
+func codeFingerprint(parsed *ParsedFile, node ast.Node) uint64 {
+	file := parsed.Fset.File(node.Pos())
+	start, end := file.Offset(node.Pos()), file.Offset(node.End())
+	src := parsed.Content[start:end]
+
+	var sc scanner.Scanner
+	sc.Init(token.NewFileSet().AddFile("", -1, len(src)), src, nil, 0)
+	h := fnv.New64a()
+	for {
+		_, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		if lit == "" {
+			lit = tok.String()
+		}
+		h.Write([]byte(lit))
+		h.Write([]byte{0})
+	}
+	// 0 表示没有代码，避免与真实的指纹冲突
+	return h.Sum64() | 1
+}
+
+// diffChanges 按DiffCommentsFrom中描述的规则配对注释并生成变更
+func diffChanges(olds, news []*diffComment) []CommentChange {
+	var changes []CommentChange
+
+	// 1、2：未变的注释，所属代码有变化时报告为stale
+	unchanged := func(o, n *diffComment) {
+		if o.code != 0 && n.code != 0 && o.code != n.code {
+			changes = append(changes, newCommentChange(ChangeStale, o, n))
+		}
+	}
+	pairComments(olds, news, func(c *diffComment) string {
+		return c.key + "\x00" + string(c.record.Kind) + "\x00" + c.record.Text
+	}, unchanged)
+	pairComments(olds, news, func(c *diffComment) string {
+		return c.decl + "\x00" + string(c.record.Kind) + "\x00" + c.record.Text
+	}, unchanged)
+
+	// 3、4：移动与修改
+	pairComments(olds, news, func(c *diffComment) string {
+		return c.record.Text
+	}, func(o, n *diffComment) {
+		changes = append(changes, newCommentChange(ChangeMoved, o, n))
+	})
+	pairComments(olds, news, func(c *diffComment) string {
+		return c.key + "\x00" + string(c.record.Kind)
+	}, func(o, n *diffComment) {
+		changes = append(changes, newCommentChange(ChangeEdited, o, n))
+	})
+
+	// 5：新增与删除
+	for _, n := range news {
+		if !n.matched {
+			changes = append(changes, newCommentChange(ChangeAdded, nil, n))
+		}
+	}
+	for _, o := range olds {
+		if !o.matched {
+			changes = append(changes, newCommentChange(ChangeRemoved, o, nil))
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Symbol != changes[j].Symbol {
+			return changes[i].Symbol < changes[j].Symbol
+		}
+		return changeLine(changes[i]) < changeLine(changes[j])
+	})
+	if changes == nil {
+		changes = []CommentChange{}
+	}
+	return changes
+}
+
+// pairComments 按identity依次配对尚未配对的新旧注释，相同identity的注释按出现顺序配对
+func pairComments(olds, news []*diffComment, identity func(c *diffComment) string, fn func(o, n *diffComment)) {
+	index := make(map[string][]*diffComment)
+	for _, c := range olds {
+		if !c.matched {
+			id := identity(c)
+			index[id] = append(index[id], c)
+		}
+	}
+	for _, n := range news {
+		if n.matched {
+			continue
+		}
+		id := identity(n)
+		candidates := index[id]
+		if len(candidates) == 0 {
+			continue
+		}
+		o := candidates[0]
+		index[id] = candidates[1:]
+		o.matched, n.matched = true, true
+		fn(o, n)
+	}
+}
+
+func newCommentChange(kind ChangeKind, o, n *diffComment) CommentChange {
+	change := CommentChange{Kind: kind}
+	if o != nil {
+		record := o.record
+		change.Old = &record
+		change.Symbol = o.key
+	}
+	if n != nil {
+		record := n.record
+		change.New = &record
+		change.Symbol = n.key
+	}
+	if kind == ChangeMoved {
+		change.OldSymbol = o.key
+	}
+	return change
+}
+
+// changeLine 返回变更在新版本中的行号，删除的注释使用旧版本中的行号
+func changeLine(c CommentChange) int {
+	if c.New != nil {
+		return c.New.Start.Line
+	}
+	return c.Old.Start.Line
+}
@@ -0,0 +1,188 @@
+package getcomments
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+const diffOldCode = `package demo
+
+// Run 运行任务
+func Run(n int) int {
+	// 初始化
+	total := 0
+	for i := 0; i < n; i++ {
+		total += i // 累加
+	}
+	return total
+}
+
+// Stop 停止
+func Stop() {
+	// 等待退出
+	wait()
+}
+
+// Start 启动服务
+func Start() {}
+
+// Helper 辅助
+func Helper() {}
+`
+
+const diffNewCode = `package demo
+
+// Run 运行任务
+func Run(n int) int {
+	// 初始化
+	total := 0
+	if n < 0 {
+		return 0
+	}
+	for i := 0; i < n; i++ {
+		total += i * 2 // 累加
+	}
+	return total
+}
+
+// Start 启动全部服务
+func Start() {
+	// 等待退出
+	wait()
+}
+
+// Helper 辅助
+func Helper() {}
+
+// Reset 重置
+func Reset() {}
+`
+
+func TestDiffComments(t *testing.T) {
+	diff, err := DiffCommentsFrom(FromSource("old.go", []byte(diffOldCode)), FromSource("new.go", []byte(diffNewCode)))
+	if err != nil {
+		t.Fatalf("比较注释失败: %v", err)
+	}
+
+	type change struct {
+		kind      ChangeKind
+		symbol    string
+		oldSymbol string
+	}
+	want := []change{
+		{ChangeAdded, "demo.Reset", ""},
+		{ChangeStale, "demo.Run", ""},
+		{ChangeStale, "demo.Run#stmt3.1", ""},
+		{ChangeEdited, "demo.Start", ""},
+		{ChangeMoved, "demo.Start#stmt1", "demo.Stop#stmt1"},
+		{ChangeRemoved, "demo.Stop", ""},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("变更数量为 %d，期望 %d: %+v", len(diff.Changes), len(want), diff.Changes)
+	}
+	for i, c := range diff.Changes {
+		got := change{c.Kind, c.Symbol, c.OldSymbol}
+		if got != want[i] {
+			t.Errorf("第 %d 个变更为 %+v，期望 %+v", i, got, want[i])
+		}
+	}
+
+	edited := diff.Changes[3]
+	if edited.Old.Text != "Start 启动服务" || edited.New.Text != "Start 启动全部服务" {
+		t.Errorf("修改前后的注释不正确: %q -> %q", edited.Old.Text, edited.New.Text)
+	}
+	if diff.Count(ChangeStale) != 2 {
+		t.Errorf("stale 数量为 %d，期望 2", diff.Count(ChangeStale))
+	}
+}
+
+func TestDiffCommentsIgnoresFormatting(t *testing.T) {
+	// 只调整格式与注释之外的空白，不应报告任何变更
+	reformatted := `package demo
+
+// Run 运行任务
+func Run(n int) int {
+	// 初始化
+	total := 0
+	for i := 0;
+		i < n; i++ {
+		total += i   // 累加
+	}
+	return total
+}
+
+// Start 启动服务
+func Start() {}
+
+// Stop 停止
+func Stop() {
+	// 等待退出
+	wait()
+}
+
+// Helper 辅助
+func Helper() {}
+`
+	diff, err := DiffCommentsFrom(FromSource("old.go", []byte(diffOldCode)), FromSource("new.go", []byte(reformatted)))
+	if err != nil {
+		t.Fatalf("比较注释失败: %v", err)
+	}
+	if len(diff.Changes) != 0 {
+		t.Errorf("期望没有变更，实际为 %+v", diff.Changes)
+	}
+}
+
+func TestDiffCommentsDir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		// 声明在同一个包的文件之间移动，不算变更
+		filepath.Join("old", "pkg", "a.go"): "package pkg\n\n// A 说明\nfunc A() {}\n",
+		filepath.Join("new", "pkg", "b.go"): "package pkg\n\n// A 说明\nfunc A() {}\n",
+		// 不同目录下的同名符号分别配对
+		filepath.Join("old", "other", "a.go"):      "package pkg\n\n// A 旧说明\nfunc A() {}\n",
+		filepath.Join("new", "other", "a.go"):      "package pkg\n\n// A 新说明\nfunc A() {}\n",
+		filepath.Join("new", "other", "broken.go"): "package pkg\n\nfunc broken( {\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("无法创建目录: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", path, err)
+		}
+	}
+
+	diff, err := DiffComments(filepath.Join(root, "old")+"/...", filepath.Join(root, "new")+"/...")
+	if err != nil {
+		t.Fatalf("比较目录失败: %v", err)
+	}
+	if len(diff.Changes) != 1 {
+		t.Fatalf("变更数量为 %d，期望 1: %+v", len(diff.Changes), diff.Changes)
+	}
+	if c := diff.Changes[0]; c.Kind != ChangeEdited || c.Symbol != "other/pkg.A" {
+		t.Errorf("变更为 %s %s，期望 edited other/pkg.A", c.Kind, c.Symbol)
+	}
+	if len(diff.Errors) != 1 {
+		t.Errorf("错误数量为 %d，期望 1: %v", len(diff.Errors), diff.Errors)
+	}
+
+	if _, err := DiffComments(filepath.Join(root, "old"), filepath.Join(root, "new", "pkg", "b.go")); err == nil {
+		t.Error("比较文件与目录应返回错误")
+	}
+}
+
+func TestDiffCommentsSkippedGenerated(t *testing.T) {
+	code := "// Code generated by x. DO NOT EDIT.\n\npackage demo\n\n// Run 运行\nfunc Run() {}\n"
+	extractor := NewExtractor(WithGenerated(generated.Skip))
+	diff, err := extractor.DiffCommentsFrom(FromSource("old.go", []byte(code)), FromSource("new.go", []byte(diffNewCode)))
+	if err != nil {
+		t.Fatalf("比较注释失败: %v", err)
+	}
+	if len(diff.Skipped) != 1 || diff.Skipped[0] != "old.go" {
+		t.Errorf("跳过的文件为 %v，期望 [old.go]", diff.Skipped)
+	}
+}
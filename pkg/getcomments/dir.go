@@ -0,0 +1,231 @@
+package getcomments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// DirResult 是目录模式下多个文件的合并提取结果
+type DirResult struct {
+	Root    string         `json:"root"`             // 计算相对路径的根目录（通常为模块根目录）
+	Files   []string       `json:"files"`            // 成功提取的文件，相对于Root
+	Records CommentRecords `json:"records"`          // 所有文件的注释记录，文件名为相对于Root的路径
+	Errors  []FileError    `json:"errors,omitempty"` // 单个文件的错误，不会中断整体提取
+
+	Generated []string `json:"generated,omitempty"` // 已提取的生成文件
+	Skipped   []string `json:"skipped,omitempty"`   // 按generated.Skip跳过的生成文件
+
+	SyntaxErrors []SyntaxError `json:"syntaxErrors,omitempty"` // 容错模式下，提取了部分结果的文件中的语法错误
+}
+
+// FileError 记录单个文件提取失败的原因
+type FileError struct {
+	File string `json:"file"`
+	Err  string `json:"error"`
+}
+
+// Error 实现error接口
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %s", e.File, e.Err)
+}
+
+// CommentsMap 将合并结果投影为"相对路径:行号"到注释的映射
+func (r *DirResult) CommentsMap() CommentsMap {
+	return r.Records.CommentsMap()
+}
+
+// IsDirPattern 判断输入是否应按目录模式处理：以"/..."结尾的模式或已存在的目录
+func IsDirPattern(input string) bool {
+	input = filepath.ToSlash(input)
+	if input == "..." || strings.HasSuffix(input, "/...") {
+		return true
+	}
+	info, err := os.Stat(input)
+	return err == nil && info.IsDir()
+}
+
+// ExtractDir 按目录模式提取注释
+// pattern 的含义与go命令一致：
+//   - "dir"     只处理该目录下的Go文件（一个包）
+//   - "dir/..." 递归处理该目录树，跳过vendor、testdata等目录
+//
+// 结果中的文件名为相对于模块根目录（向上查找go.mod）的路径，找不到go.mod时相对于dir，
+// 因此不同目录下的同名文件不会冲突。单个文件的读取或解析错误会收集到Errors中
+func ExtractDir(pattern string) (*DirResult, error) {
+	return defaultExtractor.ExtractDir(pattern)
+}
+
+// DirFiles 是目录模式匹配到的Go文件
+type DirFiles struct {
+	Root   string      // 计算相对路径的根目录
+	Inputs []Input     // 每个文件的输入，文件名为相对于Root的路径
+	Errors []FileError // 遍历目录时的错误
+}
+
+// ExtractDir 使用提取器的配置按目录模式提取注释，规则与包级ExtractDir相同
+func (e *Extractor) ExtractDir(pattern string) (*DirResult, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DirResult{Root: dir.Root, Errors: dir.Errors}
+	for i, in := range dir.Inputs {
+		if err := result.add(e.extractItem(context.Background(), i, in)); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// add 合并一个文件的提取结果，只有拒绝处理生成文件时返回错误，此时应中断整体提取
+func (r *DirResult) add(item BatchResult) error {
+	err := item.Err
+	if syntaxErrs, ok := AsSyntaxErrors(err); ok {
+		// 容错模式下保留部分结果
+		r.SyntaxErrors = append(r.SyntaxErrors, syntaxErrs...)
+		err = nil
+	}
+	switch {
+	case errors.Is(err, generated.ErrGenerated):
+		return err
+	case err != nil:
+		r.addError(item.File, err.Error())
+		return nil
+	case item.Skipped:
+		r.Skipped = append(r.Skipped, item.File)
+		return nil
+	case item.Generated:
+		r.Generated = append(r.Generated, item.File)
+	}
+	r.Files = append(r.Files, item.File)
+	r.Records = append(r.Records, item.Records...)
+	return nil
+}
+
+// ListDir 列出目录模式匹配到的Go文件，pattern 的规则与ExtractDir相同
+// 每个输入的文件名为相对于根目录的路径，与ExtractDir结果中的文件名一致
+func ListDir(pattern string) (*DirFiles, error) {
+	dir, recursive := splitDirPattern(pattern)
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("获取绝对路径失败: %v", err)
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取目录失败: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("不是目录: %s", dir)
+	}
+
+	result := &DirFiles{Root: findModuleRoot(absDir)}
+	if result.Root == "" {
+		result.Root = absDir
+	}
+
+	files, walkErrs := listGoFiles(absDir, recursive)
+	for _, walkErr := range walkErrs {
+		result.Errors = append(result.Errors, FileError{File: relativePath(result.Root, walkErr.File), Err: walkErr.Err})
+	}
+	for _, path := range files {
+		result.Inputs = append(result.Inputs, Input{path: path, filename: relativePath(result.Root, path)})
+	}
+	return result, nil
+}
+
+func (r *DirResult) addError(file, msg string) {
+	r.Errors = append(r.Errors, FileError{File: file, Err: msg})
+}
+
+// splitDirPattern 拆分目录模式，返回目录以及是否递归
+func splitDirPattern(pattern string) (string, bool) {
+	pattern = filepath.ToSlash(pattern)
+	if pattern == "..." {
+		return ".", true
+	}
+	if strings.HasSuffix(pattern, "/...") {
+		dir := strings.TrimSuffix(pattern, "/...")
+		if dir == "" {
+			dir = "/"
+		}
+		return dir, true
+	}
+	return pattern, false
+}
+
+// skipDir 判断目录是否应被跳过，与findmain保持一致
+func skipDir(name string) bool {
+	return name == "vendor" || name == ".git" || name == "node_modules" || name == "testdata"
+}
+
+// listGoFiles 列出目录下的Go文件，按路径排序
+func listGoFiles(dir string, recursive bool) ([]string, []FileError) {
+	var files []string
+	var errs []FileError
+
+	if !recursive {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, []FileError{{File: dir, Err: err.Error()}}
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+		return files, nil
+	}
+
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 跳过无法访问的目录/文件
+			errs = append(errs, FileError{File: path, Err: err.Error()})
+			return nil
+		}
+		if info.IsDir() {
+			if path != dir && skipDir(info.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(info.Name(), ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, errs
+}
+
+// findModuleRoot 从dir开始向上查找包含go.mod的目录，找不到时返回空字符串
+func findModuleRoot(dir string) string {
+	for {
+		if info, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// relativePath 返回以斜杠分隔的相对路径，失败时返回原路径
+func relativePath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
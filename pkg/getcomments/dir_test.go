@@ -0,0 +1,151 @@
+package getcomments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// 创建用于目录模式测试的临时项目
+func createDirTestProject(t *testing.T) string {
+	tempDir := t.TempDir()
+
+	files := map[string]string{
+		"go.mod": "module example.com/demo\n",
+		// 两个同名文件，合并结果中不能冲突
+		filepath.Join("cmd", "a", "main.go"): `package main
+
+// 入口a
+func main() {}
+`,
+		filepath.Join("cmd", "b", "main.go"): `package main
+
+// 入口b
+func main() {}
+`,
+		// 语法错误的文件应记录错误而不是中断
+		filepath.Join("cmd", "b", "broken.go"): `package main
+
+func broken( {
+`,
+		// vendor 和 testdata 目录应被跳过
+		filepath.Join("vendor", "dep", "dep.go"): `package dep
+
+// 依赖注释
+var X = 1
+`,
+		filepath.Join("cmd", "a", "testdata", "data.go"): `package data
+
+// 测试数据注释
+var Y = 1
+`,
+	}
+
+	for path, content := range files {
+		fullPath := filepath.Join(tempDir, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("无法创建目录 %s: %v", filepath.Dir(fullPath), err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", path, err)
+		}
+	}
+	return tempDir
+}
+
+func TestExtractDirRecursive(t *testing.T) {
+	root := createDirTestProject(t)
+
+	result, err := ExtractDir(filepath.Join(root, "..."))
+	if err != nil {
+		t.Fatalf("ExtractDir出错: %v", err)
+	}
+
+	commentsMap := result.CommentsMap()
+	expected := map[string]string{
+		"cmd/a/main.go:4": "// 入口a",
+		"cmd/b/main.go:4": "// 入口b",
+	}
+	if len(commentsMap) != len(expected) {
+		t.Errorf("得到 %d 个键, 期望 %d: %v", len(commentsMap), len(expected), commentsMap)
+	}
+	for key, want := range expected {
+		if got := commentsMap[key]; len(got) != 1 || got[0] != want {
+			t.Errorf("键 %s 的注释为 %v, 期望 [%s]", key, got, want)
+		}
+	}
+
+	if len(result.Errors) != 1 || result.Errors[0].File != "cmd/b/broken.go" {
+		t.Errorf("期望收集到 cmd/b/broken.go 的错误, 得到 %v", result.Errors)
+	}
+}
+
+func TestExtractDirPackage(t *testing.T) {
+	root := createDirTestProject(t)
+
+	// 不带"/..."时只处理该目录，键仍然相对于模块根目录
+	result, err := ExtractDir(filepath.Join(root, "cmd", "a"))
+	if err != nil {
+		t.Fatalf("ExtractDir出错: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0] != "cmd/a/main.go" {
+		t.Errorf("处理的文件为 %v, 期望 [cmd/a/main.go]", result.Files)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("不应有错误, 得到 %v", result.Errors)
+	}
+
+	if _, err := ExtractDir(filepath.Join(root, "missing")); err == nil {
+		t.Errorf("不存在的目录应返回错误")
+	}
+}
+
+func TestExtractDirGenerated(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":         "package main\n\n// 入口\nfunc main() {}\n",
+		"zz_generated.go": "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage main\n\n// 生成的注释\nvar X = 1\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", name, err)
+		}
+	}
+
+	// 默认照常提取并标记
+	result, err := ExtractDir(root)
+	if err != nil {
+		t.Fatalf("ExtractDir出错: %v", err)
+	}
+	if len(result.Generated) != 1 || result.Generated[0] != "zz_generated.go" {
+		t.Errorf("Generated = %v, 期望 [zz_generated.go]", result.Generated)
+	}
+	for _, r := range result.Records {
+		if r.Generated != (r.File == "zz_generated.go") {
+			t.Errorf("%s:%d 的Generated为 %v", r.File, r.Line, r.Generated)
+		}
+	}
+	if output := result.Records.GroupedOutput(); len(output.Generated) != 1 {
+		t.Errorf("GroupedOutput.Generated = %v, 期望 1 个文件", output.Generated)
+	}
+
+	result, err = NewExtractor(WithGenerated(generated.Skip)).ExtractDir(root)
+	if err != nil {
+		t.Fatalf("ExtractDir出错: %v", err)
+	}
+	if len(result.Skipped) != 1 || len(result.Files) != 1 || result.Files[0] != "main.go" {
+		t.Errorf("skip: Files = %v, Skipped = %v", result.Files, result.Skipped)
+	}
+	for _, r := range result.Records {
+		if r.Generated {
+			t.Errorf("skip: 不应包含生成文件的注释 %s:%d", r.File, r.Line)
+		}
+	}
+
+	if _, err := NewExtractor(WithGenerated(generated.Refuse)).ExtractDir(root); !errors.Is(err, generated.ErrGenerated) {
+		t.Errorf("refuse: 期望 ErrGenerated 错误, 得到 %v", err)
+	}
+}
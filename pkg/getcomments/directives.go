@@ -0,0 +1,352 @@
+package getcomments
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Directive 一条编译器或检查工具指令
+type Directive struct {
+	File       string   `json:"file"`
+	Pos        Position `json:"pos"`
+	Raw        string   `json:"raw"`                  // 原始注释文本
+	Name       string   `json:"name"`                 // 指令名称，如 go:build、go:embed、nolint、lint:ignore
+	Args       []string `json:"args,omitempty"`       // 指令参数
+	Target     NodeInfo `json:"target"`               // 指令作用的声明或语句，文件级指令为File
+	TargetLine int      `json:"targetLine,omitempty"` // 目标节点的起始行
+}
+
+// Diagnostic 带位置的诊断信息
+type Diagnostic struct {
+	File       string   `json:"file"`
+	Pos        Position `json:"pos"`
+	Rule       string   `json:"rule"`                 // 规则名称
+	Message    string   `json:"message"`              // 诊断说明
+	Suggestion string   `json:"suggestion,omitempty"` // 可以自动修复时，修复后的注释行
+}
+
+// String 返回 "文件:行:列: 说明 (规则)" 形式的诊断信息
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s (%s)", d.File, d.Pos.Line, d.Pos.Column, d.Message, d.Rule)
+}
+
+// DirectiveReport 一个文件中的指令及其位置诊断
+type DirectiveReport struct {
+	Directives  []Directive  `json:"directives"`
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+}
+
+// 必须直接放在函数声明上方的编译器指令
+var funcDirectives = map[string]bool{
+	"go:noinline":         true,
+	"go:nosplit":          true,
+	"go:noescape":         true,
+	"go:norace":           true,
+	"go:nocheckptr":       true,
+	"go:uintptrescapes":   true,
+	"go:uintptrkeepalive": true,
+	"go:wasmimport":       true,
+}
+
+// ExtractDirectives 提取输入中的所有指令，并检查指令的位置是否正确
+func ExtractDirectives(in Input) (*DirectiveReport, error) {
+	parsed, err := defaultExtractor.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	return newDirectiveScanner(in.Filename(), parsed).scan(), nil
+}
+
+// ParseDirective 解析单条指令注释，返回指令名称与参数；不是指令时ok为false
+// // 后带有空格的nolint指令（如 "// nolint:errcheck"）虽然不会被识别，也作为指令返回，以便报告nolint-format
+func ParseDirective(raw string) (name string, args []string, ok bool) {
+	if !IsDirective(raw) && !isSpacedNolint(raw) {
+		return "", nil, false
+	}
+	text := strings.TrimLeft(raw[2:], " ")
+
+	name, rest, _ := strings.Cut(text, " ")
+	rest = strings.TrimSpace(rest)
+	switch {
+	case name == "go:build":
+		// 构建约束是一个完整的表达式
+		if rest != "" {
+			args = []string{rest}
+		}
+	case strings.HasPrefix(name, "nolint"):
+		// //nolint:linter1,linter2 // 说明
+		name, linters, _ := strings.Cut(name, ":")
+		if linters != "" {
+			args = strings.Split(linters, ",")
+		}
+		return name, args, true
+	case name == "lint:ignore" || name == "lint:file-ignore":
+		// //lint:ignore 检查项 原因
+		checks, reason, _ := strings.Cut(rest, " ")
+		if checks != "" {
+			args = append(args, checks)
+		}
+		if reason = strings.TrimSpace(reason); reason != "" {
+			args = append(args, reason)
+		}
+	case name == "line":
+		if rest != "" {
+			args = []string{rest}
+		}
+	default:
+		args = splitDirectiveArgs(rest)
+	}
+	return name, args, true
+}
+
+// isSpacedNolint 判断注释是否是 // 后带有空格的nolint指令：nolint 后面为结尾、冒号或 " //" 说明，
+// "// nolint 不推荐使用" 这样的普通说明不算
+func isSpacedNolint(raw string) bool {
+	if !strings.HasPrefix(raw, "// ") {
+		return false
+	}
+	rest, ok := strings.CutPrefix(strings.TrimLeft(raw[2:], " "), "nolint")
+	return ok && (rest == "" || rest[0] == ':' || strings.HasPrefix(rest, " //"))
+}
+
+// splitDirectiveArgs 按空白拆分参数，支持双引号与反引号包围的参数
+func splitDirectiveArgs(s string) []string {
+	var args []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return args
+		}
+		if s[0] == '"' || s[0] == '`' {
+			if end := quotedEnd(s); end > 0 {
+				if arg, err := strconv.Unquote(s[:end]); err == nil {
+					args = append(args, arg)
+					s = s[end:]
+					continue
+				}
+			}
+		}
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		args = append(args, s[:end])
+		s = s[end:]
+	}
+}
+
+// quotedEnd 返回以引号开头的参数结束位置，找不到结束引号时返回-1
+func quotedEnd(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// directiveScanner 扫描一个文件中的指令
+type directiveScanner struct {
+	filename   string
+	fset       *token.FileSet
+	file       *ast.File
+	lines      []string
+	candidates []ast.Node // 可以作为指令目标的节点，按先序遍历顺序排列
+	topLevel   map[ast.Node]bool
+	report     *DirectiveReport
+}
+
+func newDirectiveScanner(filename string, parsed *ParsedFile) *directiveScanner {
+	s := &directiveScanner{
+		filename: filename,
+		fset:     parsed.Fset,
+		file:     parsed.File,
+		lines:    strings.Split(string(parsed.Content), "\n"),
+		topLevel: make(map[ast.Node]bool),
+		report:   &DirectiveReport{},
+	}
+	for _, decl := range parsed.File.Decls {
+		s.topLevel[decl] = true
+		if genDecl, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range genDecl.Specs {
+				s.topLevel[spec] = true
+			}
+		}
+	}
+	ast.Inspect(parsed.File, func(n ast.Node) bool {
+		switch n.(type) {
+		case ast.Decl, ast.Spec, *ast.Field:
+			s.candidates = append(s.candidates, n)
+		case ast.Stmt:
+			if _, ok := n.(*ast.BlockStmt); !ok {
+				s.candidates = append(s.candidates, n)
+			}
+		}
+		return true
+	})
+	return s
+}
+
+func (s *directiveScanner) scan() *DirectiveReport {
+	for _, cg := range s.file.Comments {
+		for _, c := range cg.List {
+			name, args, ok := ParseDirective(c.Text)
+			if !ok {
+				continue
+			}
+			pos := s.fset.Position(c.Pos())
+			d := Directive{
+				File: s.filename,
+				Pos:  Position{Line: pos.Line, Column: pos.Column},
+				Raw:  c.Text,
+				Name: name,
+				Args: args,
+			}
+			target := s.target(c, pos)
+			if target != nil {
+				d.Target = describeNode(target)
+				d.TargetLine = s.fset.Position(target.Pos()).Line
+			}
+			s.report.Directives = append(s.report.Directives, d)
+			s.validate(d, c, target)
+		}
+	}
+	return s.report
+}
+
+// target 查找指令作用的节点
+// 行尾指令作用于所在行最内层的声明或语句；独立的指令作用于其后第一个声明或语句；
+// 位于package子句之前的指令作用于整个文件
+func (s *directiveScanner) target(c *ast.Comment, pos token.Position) ast.Node {
+	if c.Pos() < s.file.Package {
+		return s.file
+	}
+	if s.isTrailing(pos) {
+		var inner ast.Node
+		for _, n := range s.candidates {
+			if n.Pos() < c.Pos() && s.fset.Position(n.Pos()).Line <= pos.Line && s.fset.Position(n.End()).Line >= pos.Line {
+				inner = n
+			}
+		}
+		return inner
+	}
+	var next ast.Node
+	for _, n := range s.candidates {
+		if n.Pos() > c.End() && (next == nil || n.Pos() < next.Pos()) {
+			next = n
+		}
+	}
+	return next
+}
+
+// isTrailing 判断注释前面是否有代码
+func (s *directiveScanner) isTrailing(pos token.Position) bool {
+	if pos.Line <= 0 || pos.Line > len(s.lines) {
+		return false
+	}
+	line := s.lines[pos.Line-1]
+	return pos.Column-1 <= len(line) && strings.TrimSpace(line[:pos.Column-1]) != ""
+}
+
+// validate 检查指令的位置与格式
+func (s *directiveScanner) validate(d Directive, c *ast.Comment, target ast.Node) {
+	switch {
+	case d.Name == "go:build" || d.Name == "+build":
+		if c.Pos() > s.file.Package {
+			s.diagnose(d, "build-placement", fmt.Sprintf("//%s 必须位于package子句之前，否则不会生效", d.Name))
+			return
+		}
+		if d.Pos.Line < len(s.lines) && strings.TrimSpace(s.lines[d.Pos.Line]) != "" &&
+			!strings.HasPrefix(strings.TrimSpace(s.lines[d.Pos.Line]), "//") {
+			s.diagnose(d, "build-separation", fmt.Sprintf("//%s 后面必须有一个空行", d.Name))
+		}
+		if d.Name == "go:build" {
+			if _, err := constraint.Parse(c.Text); err != nil {
+				s.diagnose(d, "build-syntax", fmt.Sprintf("无效的构建约束: %v", err))
+			}
+		}
+	case d.Name == "go:embed":
+		s.validateEmbed(d, c, target)
+	case d.Name == "go:generate":
+		if d.Pos.Column != 1 {
+			s.diagnose(d, "generate-placement", "//go:generate 必须从行首开始，否则go generate会忽略它")
+		}
+	case funcDirectives[d.Name]:
+		if _, ok := target.(*ast.FuncDecl); !ok || !s.adjacent(c, target) {
+			s.diagnose(d, "func-directive-placement", fmt.Sprintf("//%s 必须直接位于函数声明上方", d.Name))
+		}
+	case d.Name == "nolint":
+		if strings.HasPrefix(d.Raw, "// ") {
+			s.diagnose(d, "nolint-format", "nolint 指令的 // 后不能有空格，否则不会被识别")
+		}
+	case d.Name == "lint:ignore" || d.Name == "lint:file-ignore":
+		if len(d.Args) < 2 {
+			s.diagnose(d, "lint-ignore-format", fmt.Sprintf("//%s 需要检查项和原因", d.Name))
+		}
+	}
+}
+
+// validateEmbed 检查 //go:embed 是否直接位于单个包级变量的声明上方
+func (s *directiveScanner) validateEmbed(d Directive, c *ast.Comment, target ast.Node) {
+	var spec *ast.ValueSpec
+	switch node := target.(type) {
+	case *ast.GenDecl:
+		if node.Tok == token.VAR && len(node.Specs) == 1 {
+			spec, _ = node.Specs[0].(*ast.ValueSpec)
+		}
+	case *ast.ValueSpec:
+		spec = node
+	}
+	if spec == nil || !s.topLevel[target] || !s.adjacent(c, target) {
+		s.diagnose(d, "embed-placement", "//go:embed 必须直接位于包级var声明上方，中间只允许空行和//注释")
+		return
+	}
+	if len(spec.Names) != 1 {
+		s.diagnose(d, "embed-placement", "//go:embed 只能作用于单个变量")
+	}
+	if !s.importsEmbed() {
+		s.diagnose(d, "embed-import", `使用 //go:embed 的文件必须导入 "embed"`)
+	}
+}
+
+// adjacent 判断指令与目标节点之间是否只有空行和//注释
+func (s *directiveScanner) adjacent(c *ast.Comment, target ast.Node) bool {
+	if target == nil {
+		return false
+	}
+	from := s.fset.Position(c.End()).Line
+	to := s.fset.Position(target.Pos()).Line
+	for line := from + 1; line < to; line++ {
+		text := strings.TrimSpace(s.lines[line-1])
+		if text != "" && !strings.HasPrefix(text, "//") {
+			return false
+		}
+	}
+	return from < to
+}
+
+func (s *directiveScanner) importsEmbed() bool {
+	for _, imp := range s.file.Imports {
+		if imp.Path.Value == `"embed"` {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *directiveScanner) diagnose(d Directive, rule, message string) {
+	s.report.Diagnostics = append(s.report.Diagnostics, Diagnostic{
+		File:    d.File,
+		Pos:     d.Pos,
+		Rule:    rule,
+		Message: message,
+	})
+}
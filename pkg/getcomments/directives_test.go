@@ -0,0 +1,127 @@
+package getcomments
+
+import (
+	"reflect"
+	"testing"
+)
+
+const directiveTestCode = `//go:build linux && amd64
+
+package p
+
+import _ "embed"
+
+//go:generate stringer -type=Color "-linecomment"
+type Color int
+
+//go:embed static/index.html
+var index string
+
+//go:embed a.txt
+var a, b string
+
+//go:noinline
+func run() {
+	x := 1 //nolint:ineffassign,staticcheck // 故意赋值
+	//lint:ignore SA4006 示例
+	x = 2
+	// nolint
+	_ = x
+	//go:generate echo 缩进的指令
+	//go:embed inner.txt
+	var y string
+	_ = y
+}
+
+//go:build ignore
+`
+
+func TestExtractDirectives(t *testing.T) {
+	report, err := ExtractDirectives(FromSource("p.go", []byte(directiveTestCode)))
+	if err != nil {
+		t.Fatalf("提取指令失败: %v", err)
+	}
+
+	type directive struct {
+		line   int
+		name   string
+		args   []string
+		target string
+	}
+	want := []directive{
+		{1, "go:build", []string{"linux && amd64"}, "File p"},
+		{7, "go:generate", []string{"stringer", "-type=Color", "-linecomment"}, "GenDecl type"},
+		{10, "go:embed", []string{"static/index.html"}, "GenDecl var"},
+		{13, "go:embed", []string{"a.txt"}, "GenDecl var"},
+		{16, "go:noinline", nil, "FuncDecl run"},
+		{18, "nolint", []string{"ineffassign", "staticcheck"}, "AssignStmt"},
+		{19, "lint:ignore", []string{"SA4006", "示例"}, "AssignStmt"},
+		{21, "nolint", nil, "AssignStmt"},
+		{23, "go:generate", []string{"echo", "缩进的指令"}, "DeclStmt"},
+		{24, "go:embed", []string{"inner.txt"}, "DeclStmt"},
+		{29, "go:build", []string{"ignore"}, ""},
+	}
+	var got []directive
+	for _, d := range report.Directives {
+		got = append(got, directive{d.Pos.Line, d.Name, d.Args, d.Target.String()})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("指令为\n%v\n期望\n%v", got, want)
+	}
+
+	wantDiagnostics := map[int]string{
+		13: "embed-placement",
+		21: "nolint-format",
+		23: "generate-placement",
+		24: "embed-placement",
+		29: "build-placement",
+	}
+	gotDiagnostics := make(map[int]string)
+	for _, d := range report.Diagnostics {
+		gotDiagnostics[d.Pos.Line] = d.Rule
+	}
+	if !reflect.DeepEqual(gotDiagnostics, wantDiagnostics) {
+		t.Errorf("诊断为 %v, 期望 %v", report.Diagnostics, wantDiagnostics)
+	}
+}
+
+func TestExtractDirectivesBuildSeparation(t *testing.T) {
+	src := "//go:build linux &&\npackage p\n"
+	report, err := ExtractDirectives(FromSource("p.go", []byte(src)))
+	if err != nil {
+		t.Fatalf("提取指令失败: %v", err)
+	}
+	var rules []string
+	for _, d := range report.Diagnostics {
+		rules = append(rules, d.Rule)
+	}
+	if want := []string{"build-separation", "build-syntax"}; !reflect.DeepEqual(rules, want) {
+		t.Errorf("诊断规则为 %v, 期望 %v", rules, want)
+	}
+}
+
+func TestParseDirective(t *testing.T) {
+	testCases := []struct {
+		raw  string
+		name string
+		args []string
+		ok   bool
+	}{
+		{"//go:embed \"with space.txt\" `raw.txt` *.css", "go:embed", []string{"with space.txt", "raw.txt", "*.css"}, true},
+		{"// +build linux darwin", "+build", []string{"linux", "darwin"}, true},
+		{"//nolint", "nolint", nil, true},
+		{"// nolint", "nolint", nil, true},
+		{"// nolint 不推荐使用", "", nil, false},
+		{"//nolintable", "", nil, false},
+		{"//lint:file-ignore U1000 未使用", "lint:file-ignore", []string{"U1000", "未使用"}, true},
+		{"//line foo.go:10", "line", []string{"foo.go:10"}, true},
+		{"//export Foo", "export", []string{"Foo"}, true},
+		{"// TODO: 处理y", "", nil, false},
+	}
+	for _, tc := range testCases {
+		name, args, ok := ParseDirective(tc.raw)
+		if name != tc.name || !reflect.DeepEqual(args, tc.args) || ok != tc.ok {
+			t.Errorf("ParseDirective(%q) = %q, %q, %v, 期望 %q, %q, %v", tc.raw, name, args, ok, tc.name, tc.args, tc.ok)
+		}
+	}
+}
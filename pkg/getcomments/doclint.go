@@ -0,0 +1,315 @@
+package getcomments
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DocLintResult 文档注释检查的结果
+type DocLintResult struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Source      []byte       `json:"-"`     // 自动修复后的源代码，没有可修复的问题时与输入相同
+	Fixed       int          `json:"fixed"` // 可自动修复的问题数
+}
+
+var (
+	// 行首的标识符
+	leadingIdentPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
+	// 重复的注释前缀，如 "ExtractComments// 从文件路径..."
+	doubledPrefixPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*//+\s*`)
+	// 任意大小写的 deprecated 段落
+	deprecatedPattern = regexp.MustCompile(`(?i)^deprecated\b\s*:?\s*`)
+)
+
+// LintDocs 检查输入中的文档注释是否符合Go的约定
+func LintDocs(in Input) (*DocLintResult, error) {
+	return defaultExtractor.LintDocs(in)
+}
+
+// LintDocs 使用提取器的配置检查文档注释，规则包括：
+//   - doc-prefix     导出标识符的文档以名称开头（允许 A、An、The 前缀），包文档以 "Package 包名" 开头
+//   - doubled-prefix 名称后重复的注释符号，如 "// ExtractComments// 说明"
+//   - sentence       文档是完整的句子：不能只有名称，英文文档以句末标点结尾
+//   - deprecated     废弃说明使用单独的段落，并以 "Deprecated: " 开头
+//
+// doc-prefix、doubled-prefix 与 deprecated 的大小写问题可以自动修复：只改写开头的标识符，其余文本保持不变。
+// 修复结果保存在Source中，调用方决定是否写回
+func (e *Extractor) LintDocs(in Input) (*DocLintResult, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	l := &docLinter{
+		fset:     parsed.Fset,
+		filename: in.Filename(),
+		result:   &DocLintResult{},
+	}
+
+	if doc := parsed.File.Doc; doc != nil {
+		l.check("Package "+parsed.File.Name.Name, doc, true)
+	}
+	for _, decl := range parsed.File.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			exported := d.Name.IsExported()
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				exported = exported && ast.IsExported(receiverTypeName(d.Recv.List[0].Type))
+			}
+			l.check(d.Name.Name, d.Doc, exported)
+		case *ast.GenDecl:
+			l.checkGenDecl(d)
+		}
+	}
+
+	applied := &ApplyResult{}
+	if err := applied.apply(parsed, l.edits); err != nil {
+		return nil, err
+	}
+	l.result.Source = applied.Source
+	return l.result, nil
+}
+
+// docLinter 检查一个文件的文档注释
+type docLinter struct {
+	fset     *token.FileSet
+	filename string
+	result   *DocLintResult
+	edits    []commentEdit
+}
+
+// checkGenDecl 检查一般声明的文档：单独声明使用声明的文档，分组声明中的规格使用各自的文档
+func (l *docLinter) checkGenDecl(genDecl *ast.GenDecl) {
+	if !genDecl.Lparen.IsValid() && len(genDecl.Specs) == 1 {
+		name, exported := specName(genDecl.Specs[0])
+		l.check(name, genDecl.Doc, exported)
+		return
+	}
+	// 分组的文档描述整个分组，只检查格式
+	l.check("", genDecl.Doc, false)
+	for _, spec := range genDecl.Specs {
+		var doc *ast.CommentGroup
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			doc = s.Doc
+		case *ast.ValueSpec:
+			doc = s.Doc
+		}
+		name, exported := specName(spec)
+		l.check(name, doc, exported)
+	}
+}
+
+// specName 返回规格声明的名称，声明多个名称时返回空字符串
+func specName(spec ast.Spec) (string, bool) {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		return s.Name.Name, s.Name.IsExported()
+	case *ast.ValueSpec:
+		if len(s.Names) == 1 {
+			return s.Names[0].Name, s.Names[0].IsExported()
+		}
+	}
+	return "", false
+}
+
+// docLine 文档中的一行 // 注释
+type docLine struct {
+	comment *ast.Comment
+	prefix  string // "//" 及其后的空白
+	text    string
+	deleted bool
+}
+
+func (d *docLine) raw() string {
+	return d.prefix + d.text
+}
+
+// check 检查一个文档注释组，name 为空时只检查格式，exported 为false时不检查名称前缀与句子
+func (l *docLinter) check(name string, doc *ast.CommentGroup, exported bool) {
+	if doc == nil {
+		return
+	}
+	var lines []*docLine
+	for _, c := range doc.List {
+		if !strings.HasPrefix(c.Text, "//") || IsDirective(c.Text) {
+			// 块注释与指令不参与检查
+			if strings.HasPrefix(c.Text, "/*") {
+				return
+			}
+			continue
+		}
+		text := strings.TrimLeft(c.Text[2:], " \t")
+		lines = append(lines, &docLine{comment: c, prefix: c.Text[:len(c.Text)-len(text)], text: text})
+	}
+	if len(lines) == 0 {
+		return
+	}
+	for _, line := range lines {
+		if m := doubledPrefixPattern.FindStringSubmatch(line.text); m != nil {
+			fixed := m[1] + " " + line.text[len(m[0]):]
+			l.diagnose(line.comment, "doubled-prefix", fmt.Sprintf("%s 后重复了注释符号", m[1]), line.prefix+fixed)
+			line.text = fixed
+		}
+	}
+	l.checkDeprecated(lines)
+
+	if exported && name != "" {
+		l.checkPrefix(name, lines)
+		l.checkSentence(name, lines)
+	}
+
+	for _, line := range lines {
+		if line.deleted {
+			l.deleteLine(line.comment)
+		} else if line.raw() != line.comment.Text {
+			l.replaceLine(line.comment, line.raw())
+		}
+	}
+}
+
+// checkPrefix 检查文档是否以名称开头
+// 开头是一个看起来像标识符的单词（如改名前的旧名称）时替换该单词，否则在开头插入名称。
+// 第一段中后面的某一行以名称开头、且前面的行只是它的重复时，删除前面的行
+func (l *docLinter) checkPrefix(name string, lines []*docLine) {
+	first := lines[0]
+	if docStartsWithName(first.text, name) {
+		return
+	}
+	message := fmt.Sprintf("文档应以 %s 开头", name)
+
+	for i := 1; i < len(lines) && lines[i].text != ""; i++ {
+		if !docStartsWithName(lines[i].text, name) {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(lines[i].text, name))
+		redundant := true
+		for _, prev := range lines[:i] {
+			if !strings.Contains(rest, strings.TrimSpace(prev.text)) {
+				redundant = false
+			}
+		}
+		if !redundant {
+			l.diagnose(first.comment, "doc-prefix", fmt.Sprintf("%s，第 %d 行以名称开头的说明应作为第一行", message, i+1), "")
+			return
+		}
+		for _, prev := range lines[:i] {
+			prev.deleted = true
+		}
+		l.diagnose(first.comment, "doc-prefix", message, lines[i].raw())
+		return
+	}
+
+	if first.prefix == "//" {
+		first.prefix = "// "
+	}
+	word := leadingIdentPattern.FindString(first.text)
+	if word != "" && (strings.EqualFold(word, name) || looksLikeIdentifier(word, first.text[len(word):])) {
+		first.text = name + first.text[len(word):]
+	} else {
+		first.text = name + " " + first.text
+	}
+	l.diagnose(first.comment, "doc-prefix", message, first.raw())
+}
+
+// looksLikeIdentifier 判断文档开头的单词是否是一个标识符而不是普通单词：
+// 包含下划线、数字或首字母之后的大写字母，或者直接与后面的非ASCII文字相连
+func looksLikeIdentifier(word, rest string) bool {
+	for i, r := range word {
+		if r == '_' || unicode.IsDigit(r) || i > 0 && unicode.IsUpper(r) {
+			return true
+		}
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return rest != "" && r >= utf8.RuneSelf
+}
+
+// checkSentence 检查第一段是否是完整的句子
+func (l *docLinter) checkSentence(name string, lines []*docLine) {
+	var paragraph []string
+	var last *ast.Comment
+	for _, line := range lines {
+		if line.deleted {
+			continue
+		}
+		if line.text == "" {
+			break
+		}
+		paragraph = append(paragraph, line.text)
+		last = line.comment
+	}
+	text := strings.TrimSpace(strings.Join(paragraph, " "))
+	if text == "" || strings.HasPrefix(text, "Deprecated:") {
+		return
+	}
+	for _, article := range []string{"", "A ", "An ", "The "} {
+		if text == article+name {
+			l.diagnose(lines[0].comment, "sentence", "文档只有名称，不是完整的句子", "")
+			return
+		}
+	}
+	if isASCII(text) {
+		r, _ := utf8.DecodeLastRuneInString(text)
+		if !strings.ContainsRune(".!?:)`", r) {
+			l.diagnose(last, "sentence", "英文文档的第一段应以句末标点结尾", "")
+		}
+	}
+}
+
+// checkDeprecated 检查废弃说明：必须以 "Deprecated: " 开头，并且是单独的段落
+func (l *docLinter) checkDeprecated(lines []*docLine) {
+	for i, line := range lines {
+		m := deprecatedPattern.FindString(line.text)
+		if m == "" {
+			continue
+		}
+		paragraphStart := i == 0 || lines[i-1].text == ""
+		if !paragraphStart && !strings.HasPrefix(line.text, "Deprecated:") {
+			// 段落中间以 deprecated 开头的普通句子
+			continue
+		}
+		if !strings.HasPrefix(line.text, "Deprecated: ") {
+			line.text = "Deprecated: " + line.text[len(m):]
+			l.diagnose(line.comment, "deprecated", `废弃说明应以 "Deprecated: " 开头`, line.raw())
+		}
+		if !paragraphStart {
+			l.diagnose(line.comment, "deprecated", "废弃说明应是单独的段落，前面需要一个空的 // 行", "")
+		}
+	}
+}
+
+func (l *docLinter) diagnose(c *ast.Comment, rule, message, suggestion string) {
+	pos := l.fset.Position(c.Pos())
+	l.result.Diagnostics = append(l.result.Diagnostics, Diagnostic{
+		File:       l.filename,
+		Pos:        Position{Line: pos.Line, Column: pos.Column},
+		Rule:       rule,
+		Message:    message,
+		Suggestion: suggestion,
+	})
+	if suggestion != "" {
+		l.result.Fixed++
+	}
+}
+
+func (l *docLinter) replaceLine(c *ast.Comment, raw string) {
+	start, end := l.fset.Position(c.Pos()).Offset, l.fset.Position(c.End()).Offset
+	l.edits = append(l.edits, commentEdit{start: start, end: end, raw: raw})
+}
+
+// deleteLine 删除一行注释，包括其后的换行与下一行的缩进
+func (l *docLinter) deleteLine(c *ast.Comment) {
+	start := l.fset.Position(c.Pos()).Offset
+	end := l.fset.Position(c.End()).Offset
+	file := l.fset.File(c.Pos())
+	line := l.fset.Position(c.Pos()).Line
+	if line < file.LineCount() {
+		// 删除到下一行相同缩进之后
+		end = file.Offset(file.LineStart(line+1)) + (start - file.Offset(file.LineStart(line)))
+	}
+	l.edits = append(l.edits, commentEdit{start: start, end: end})
+}
@@ -0,0 +1,108 @@
+package getcomments
+
+import (
+	"reflect"
+	"testing"
+)
+
+const docLintTestCode = `// 示例包
+package p
+
+// 从文件提取注释
+// ExtractComments// 从文件提取注释并返回映射
+//
+// deprecated 请使用ExtractCommentsFrom
+func ExtractComments() {}
+
+// 保存代码行及其关联的注释
+type CommentsMap map[string][]string
+
+// OldName returns the name
+func NewName() {}
+
+//go:generate echo
+// Color 颜色
+type Color int
+
+// Reader
+type Reader interface{}
+
+// Run 运行任务
+// Deprecated: 请使用Start
+func Run() {}
+
+// 内部函数不检查前缀
+func helper() {}
+`
+
+const docLintFixedCode = `// Package p 示例包
+package p
+
+// ExtractComments 从文件提取注释并返回映射
+//
+// Deprecated: 请使用ExtractCommentsFrom
+func ExtractComments() {}
+
+// CommentsMap 保存代码行及其关联的注释
+type CommentsMap map[string][]string
+
+// NewName returns the name
+func NewName() {}
+
+//go:generate echo
+// Color 颜色
+type Color int
+
+// Reader
+type Reader interface{}
+
+// Run 运行任务
+// Deprecated: 请使用Start
+func Run() {}
+
+// 内部函数不检查前缀
+func helper() {}
+`
+
+func TestLintDocs(t *testing.T) {
+	result, err := LintDocs(FromSource("p.go", []byte(docLintTestCode)))
+	if err != nil {
+		t.Fatalf("检查文档失败: %v", err)
+	}
+
+	type diagnostic struct {
+		line  int
+		rule  string
+		fixed bool
+	}
+	want := []diagnostic{
+		{1, "doc-prefix", true},
+		{5, "doubled-prefix", true},
+		{7, "deprecated", true},
+		{4, "doc-prefix", true},
+		{10, "doc-prefix", true},
+		{13, "doc-prefix", true},
+		{13, "sentence", false},
+		{20, "sentence", false},
+		{24, "deprecated", false},
+	}
+	var got []diagnostic
+	for _, d := range result.Diagnostics {
+		got = append(got, diagnostic{d.Pos.Line, d.Rule, d.Suggestion != ""})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("诊断为\n%v\n期望\n%v", got, want)
+	}
+	if string(result.Source) != docLintFixedCode {
+		t.Errorf("修复后的代码为\n%s\n期望\n%s", result.Source, docLintFixedCode)
+	}
+
+	// 修复后的代码只剩下无法自动修复的问题
+	fixed, err := LintDocs(FromSource("p.go", result.Source))
+	if err != nil {
+		t.Fatalf("检查文档失败: %v", err)
+	}
+	if fixed.Fixed != 0 || len(fixed.Diagnostics) != 3 {
+		t.Errorf("修复后仍有可修复的问题: %v", fixed.Diagnostics)
+	}
+}
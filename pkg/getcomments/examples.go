@@ -0,0 +1,277 @@
+package getcomments
+
+import (
+	"go/ast"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExampleOutput 示例函数中的输出注释
+type ExampleOutput struct {
+	Pos       Position `json:"pos"`
+	Unordered bool     `json:"unordered"` // 是否是 "Unordered output:"
+	Text      string   `json:"text"`      // 期望的输出，不包括 "Output:" 前缀，与go test比较的内容一致
+	Final     bool     `json:"final"`     // 是否是函数体中的最后一条注释，不是时go test不会检查输出
+}
+
+// Example 一个示例函数
+type Example struct {
+	File     string         `json:"file"`
+	Package  string         `json:"package"` // 文件所在目录；位于根目录时为被测试的包名
+	Pos      Position       `json:"pos"`
+	Name     string         `json:"name"`             // 函数名，如 ExampleExtractor_Extract_dir
+	Target   string         `json:"target,omitempty"` // 示例说明的标识符，如 Extractor.Extract，包示例为空
+	Suffix   string         `json:"suffix,omitempty"` // 名称末尾以小写字母开头的后缀，如 dir
+	Output   *ExampleOutput `json:"output,omitempty"` // 输出注释，没有时示例只编译不运行
+	Runnable bool           `json:"runnable"`         // go test 是否会运行该示例并检查输出
+}
+
+// ExampleReport 示例函数的检查结果
+type ExampleReport struct {
+	Examples    []Example    `json:"examples"`
+	Missing     []string     `json:"missing"` // 没有输出注释的示例，格式为 "包.函数名"
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Errors      []FileError  `json:"errors,omitempty"`
+}
+
+// 输出注释的前缀，与go/doc的规则相同
+var outputPrefix = regexp.MustCompile(`(?i)^[[:space:]]*(unordered )?output:`)
+
+// FindExamples 检查输入中的示例函数
+func FindExamples(in Input) (*ExampleReport, error) {
+	return defaultExtractor.FindExamples(in)
+}
+
+// FindExamplesDir 按目录模式检查示例函数，pattern 的规则与ExtractDir相同
+func FindExamplesDir(pattern string) (*ExampleReport, error) {
+	return defaultExtractor.FindExamplesDir(pattern)
+}
+
+// FindExamples 使用提取器的配置检查输入中的示例函数
+// 只有 _test.go 文件中名为 Example、ExampleF、ExampleT、ExampleT_M 以及带有 _suffix 后缀的函数是示例。
+// 检查的内容：
+//   - 输出注释（"// Output:" 或 "// Unordered output:"）必须是函数体中的最后一条注释，否则go test会忽略它
+//   - 名称中的标识符、字段或方法必须在包中存在，不考虑通过嵌入字段提升的方法
+//   - 后缀必须以小写字母开头，示例函数不能有参数、返回值与类型参数
+//
+// 文件输入会读取同一目录中其他文件的包级声明来解析名称，源代码与读取器输入只能看到自身的声明
+func (e *Extractor) FindExamples(in Input) (*ExampleReport, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	scope := newPackageScope()
+	if in.Path() == "" {
+		scope.addFile(parsed.File)
+	} else {
+		paths, _ := filepath.Glob(filepath.Join(filepath.Dir(in.Path()), "*.go"))
+		for _, p := range paths {
+			sibling, err := e.parse(FromFile(p))
+			if err == nil && !strings.HasSuffix(sibling.File.Name.Name, "_test") {
+				scope.addFile(sibling.File)
+			}
+		}
+	}
+	report := newExampleReport()
+	report.addFile(in.Filename(), parsed, scope)
+	return report, nil
+}
+
+// FindExamplesDir 使用提取器的配置按目录模式检查示例函数，同一目录中非外部测试包的文件共用包级声明
+func (e *Extractor) FindExamplesDir(pattern string) (*ExampleReport, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	report := newExampleReport()
+	report.Errors = dir.Errors
+
+	type file struct {
+		filename string
+		parsed   *ParsedFile
+	}
+	scopes := make(map[string]*packageScope)
+	var tests []file
+	for _, in := range dir.Inputs {
+		parsed, err := e.parse(in)
+		if err != nil {
+			report.Errors = append(report.Errors, FileError{File: in.Filename(), Err: err.Error()})
+			continue
+		}
+		pkg := path.Dir(in.Filename())
+		if scopes[pkg] == nil {
+			scopes[pkg] = newPackageScope()
+		}
+		if !strings.HasSuffix(parsed.File.Name.Name, "_test") {
+			scopes[pkg].addFile(parsed.File)
+		}
+		if strings.HasSuffix(in.Filename(), "_test.go") {
+			tests = append(tests, file{in.Filename(), parsed})
+		}
+	}
+
+	for _, f := range tests {
+		report.addFile(f.filename, f.parsed, scopes[path.Dir(f.filename)])
+	}
+	return report, nil
+}
+
+func newExampleReport() *ExampleReport {
+	return &ExampleReport{Examples: []Example{}, Missing: []string{}, Diagnostics: []Diagnostic{}}
+}
+
+// addFile 检查一个文件中的示例函数
+func (r *ExampleReport) addFile(filename string, parsed *ParsedFile, scope *packageScope) {
+	if !strings.HasSuffix(filename, "_test.go") {
+		return
+	}
+	pkg := path.Dir(filename)
+	if pkg == "." {
+		// 外部测试包中的示例属于被测试的包
+		pkg = strings.TrimSuffix(parsed.File.Name.Name, "_test")
+	}
+	for _, decl := range parsed.File.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv != nil || funcDecl.Body == nil || !isExampleName(funcDecl.Name.Name) {
+			continue
+		}
+		pos := parsed.Fset.Position(funcDecl.Pos())
+		ex := Example{
+			File:    filename,
+			Package: pkg,
+			Pos:     Position{Line: pos.Line, Column: pos.Column},
+			Name:    funcDecl.Name.Name,
+		}
+		c := &exampleChecker{report: r, parsed: parsed, example: &ex}
+		c.checkSignature(funcDecl)
+		c.checkName(scope)
+		c.checkOutput(funcDecl.Body)
+
+		ex.Runnable = ex.Output != nil && ex.Output.Final
+		if ex.Output == nil {
+			r.Missing = append(r.Missing, pkg+"."+ex.Name)
+		}
+		r.Examples = append(r.Examples, ex)
+	}
+}
+
+// isExampleName 判断函数名是否是示例：Example 或 Example 后面不是小写字母，规则与go test相同
+func isExampleName(name string) bool {
+	if !strings.HasPrefix(name, "Example") {
+		return false
+	}
+	if len(name) == len("Example") {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(name[len("Example"):])
+	return !unicode.IsLower(r)
+}
+
+// isExampleSuffix 判断名称中的一段是否是后缀，即以小写字母开头
+func isExampleSuffix(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size > 0 && unicode.IsLower(r)
+}
+
+// exampleChecker 检查一个示例函数
+type exampleChecker struct {
+	report  *ExampleReport
+	parsed  *ParsedFile
+	example *Example
+}
+
+func (c *exampleChecker) checkSignature(funcDecl *ast.FuncDecl) {
+	t := funcDecl.Type
+	if t.Params.NumFields() > 0 || t.Results.NumFields() > 0 || t.TypeParams.NumFields() > 0 {
+		c.diagnose(c.example.Pos, "example-signature", "示例函数 "+c.example.Name+" 不能有参数、返回值或类型参数")
+	}
+}
+
+// checkName 解析名称中的标识符、字段或方法以及后缀，规则与go vet的tests检查相同
+func (c *exampleChecker) checkName(scope *packageScope) {
+	name := c.example.Name
+	if name == "Example" {
+		return
+	}
+	rest := strings.TrimPrefix(name, "Example")
+	elems := strings.SplitN(rest, "_", 3)
+	ident := elems[0]
+	if ident == "" {
+		// Example_suffix
+		suffix := strings.TrimPrefix(rest, "_")
+		c.example.Suffix = suffix
+		if !isExampleSuffix(suffix) {
+			c.diagnose(c.example.Pos, "example-suffix", name+" 的后缀 "+suffix+" 必须以小写字母开头")
+		}
+		return
+	}
+	if !scope.declared[ident] {
+		c.diagnose(c.example.Pos, "example-unknown-name", name+" 引用了不存在的标识符 "+ident)
+		return
+	}
+	c.example.Target = ident
+	if len(elems) == 1 {
+		return
+	}
+
+	member := elems[1]
+	if isExampleSuffix(member) {
+		// ExampleT_suffix
+		c.example.Suffix = strings.Join(elems[1:], "_")
+	} else if scope.members[ident][member] {
+		c.example.Target = ident + "." + member
+	} else {
+		c.diagnose(c.example.Pos, "example-unknown-name", name+" 引用了不存在的字段或方法 "+ident+"."+member)
+		return
+	}
+	if len(elems) == 3 && !isExampleSuffix(elems[2]) {
+		c.diagnose(c.example.Pos, "example-suffix", name+" 的后缀 "+elems[2]+" 必须以小写字母开头")
+	} else if len(elems) == 3 && c.example.Target != ident {
+		c.example.Suffix = elems[2]
+	}
+}
+
+// checkOutput 查找函数体中的输出注释：go test 只使用函数体中的最后一个注释组，
+// 其他位置的输出注释会被忽略，示例因此只编译不运行
+func (c *exampleChecker) checkOutput(body *ast.BlockStmt) {
+	var groups []*ast.CommentGroup
+	for _, cg := range c.parsed.File.Comments {
+		if cg.Pos() > body.Lbrace && cg.End() < body.Rbrace {
+			groups = append(groups, cg)
+		}
+	}
+	for i, cg := range groups {
+		text := cg.Text()
+		loc := outputPrefix.FindStringSubmatchIndex(text)
+		if loc == nil {
+			continue
+		}
+		// 去掉前缀后面的空格以及紧跟的一个换行
+		output := strings.TrimLeft(text[loc[1]:], " ")
+		output = strings.TrimPrefix(output, "\n")
+		start := c.parsed.Fset.Position(cg.Pos())
+		c.example.Output = &ExampleOutput{
+			Pos:       Position{Line: start.Line, Column: start.Column},
+			Unordered: loc[2] != -1,
+			Text:      output,
+			Final:     i == len(groups)-1,
+		}
+		if !c.example.Output.Final {
+			c.diagnose(c.example.Output.Pos, "example-output-placement",
+				"输出注释不是 "+c.example.Name+" 函数体中的最后一条注释，go test 不会检查该示例的输出")
+		}
+	}
+}
+
+func (c *exampleChecker) diagnose(pos Position, rule, message string) {
+	c.report.Diagnostics = append(c.report.Diagnostics, Diagnostic{
+		File:    c.example.File,
+		Pos:     pos,
+		Rule:    rule,
+		Message: message,
+	})
+}
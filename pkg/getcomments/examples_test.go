@@ -0,0 +1,200 @@
+package getcomments
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const examplesCode = `package demo
+
+import "fmt"
+
+type Server struct {
+	Addr string
+}
+
+func (s *Server) Start() {}
+
+func Run() {}
+
+func Example() {
+	fmt.Println("hello")
+	// Output: hello
+}
+
+func ExampleRun() {
+	// Output:
+	// line 1
+	// line 2
+	Run()
+	// 运行结束
+}
+
+func ExampleServer_Start_tls() {
+	// 启动服务
+	(&Server{}).Start()
+	// Unordered output:
+	// b
+	// a
+}
+
+func ExampleServer_Addr() {
+	fmt.Println(Server{}.Addr)
+}
+
+func ExampleServer_config() {
+	// 只编译不运行
+}
+
+func ExampleStop() {
+	// Output: stop
+}
+
+func ExampleServer_Stop() {}
+
+func ExampleServer_Start_TLS() {}
+
+func Example_multiple() {}
+
+func ExampleRun_args(n int) {}
+
+func Examplefoo() {}
+
+func (s *Server) ExampleServer() {}
+`
+
+func TestFindExamples(t *testing.T) {
+	report, err := FindExamples(FromSource("demo_test.go", []byte(examplesCode)))
+	if err != nil {
+		t.Fatalf("检查示例失败: %v", err)
+	}
+
+	type example struct {
+		name, target, suffix string
+		runnable             bool
+	}
+	want := []example{
+		{"Example", "", "", true},
+		{"ExampleRun", "Run", "", false},
+		{"ExampleServer_Start_tls", "Server.Start", "tls", true},
+		{"ExampleServer_Addr", "Server.Addr", "", false},
+		{"ExampleServer_config", "Server", "config", false},
+		{"ExampleStop", "", "", true},
+		{"ExampleServer_Stop", "Server", "", false},
+		{"ExampleServer_Start_TLS", "Server.Start", "", false},
+		{"Example_multiple", "", "multiple", false},
+		{"ExampleRun_args", "Run", "args", false},
+	}
+	if len(report.Examples) != len(want) {
+		t.Fatalf("示例数量为 %d，期望 %d: %+v", len(report.Examples), len(want), report.Examples)
+	}
+	for i, ex := range report.Examples {
+		got := example{ex.Name, ex.Target, ex.Suffix, ex.Runnable}
+		if got != want[i] {
+			t.Errorf("第 %d 个示例为 %+v，期望 %+v", i, got, want[i])
+		}
+	}
+
+	if out := report.Examples[1].Output; out == nil || out.Final || out.Text != "line 1\nline 2\n" {
+		t.Errorf("ExampleRun 的输出注释不正确: %+v", out)
+	}
+	if out := report.Examples[2].Output; out == nil || !out.Unordered || out.Text != "b\na\n" {
+		t.Errorf("ExampleServer_Start_tls 的输出注释不正确: %+v", out)
+	}
+
+	wantMissing := []string{
+		"demo.ExampleServer_Addr", "demo.ExampleServer_config", "demo.ExampleServer_Stop",
+		"demo.ExampleServer_Start_TLS", "demo.Example_multiple", "demo.ExampleRun_args",
+	}
+	if len(report.Missing) != len(wantMissing) {
+		t.Fatalf("缺少输出的示例为 %v，期望 %v", report.Missing, wantMissing)
+	}
+	for i, name := range wantMissing {
+		if report.Missing[i] != name {
+			t.Errorf("第 %d 个缺少输出的示例为 %s，期望 %s", i, report.Missing[i], name)
+		}
+	}
+
+	type diagnostic struct {
+		line int
+		rule string
+	}
+	wantDiags := []diagnostic{
+		{19, "example-output-placement"},
+		{42, "example-unknown-name"},
+		{46, "example-unknown-name"},
+		{48, "example-suffix"},
+		{52, "example-signature"},
+	}
+	if len(report.Diagnostics) != len(wantDiags) {
+		t.Fatalf("诊断数量为 %d，期望 %d: %v", len(report.Diagnostics), len(wantDiags), report.Diagnostics)
+	}
+	for i, d := range report.Diagnostics {
+		got := diagnostic{d.Pos.Line, d.Rule}
+		if got != wantDiags[i] {
+			t.Errorf("第 %d 个诊断为 %+v，期望 %+v: %s", i, got, wantDiags[i], d.Message)
+		}
+	}
+}
+
+func TestFindExamplesIgnoresNonTestFiles(t *testing.T) {
+	report, err := FindExamples(FromSource("demo.go", []byte(examplesCode)))
+	if err != nil {
+		t.Fatalf("检查示例失败: %v", err)
+	}
+	if len(report.Examples) != 0 || len(report.Diagnostics) != 0 {
+		t.Errorf("非测试文件不应包含示例: %+v", report)
+	}
+}
+
+func TestFindExamplesDir(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		filepath.Join("pkg", "pkg.go"): "package pkg\n\ntype T struct{}\n\nfunc (T) M() {}\n",
+		// 外部测试包中的示例引用被测试包中的声明
+		filepath.Join("pkg", "example_test.go"): "package pkg_test\n\nfunc ExampleT_M() {\n\t// Output: ok\n}\n\nfunc ExampleHelper() {}\n",
+		// 外部测试包自己的声明不能作为示例的名称
+		filepath.Join("pkg", "helper_test.go"): "package pkg_test\n\nfunc Helper() {}\n",
+		// 其他目录中的声明不可见
+		filepath.Join("other", "other.go"):      "package other\n\nfunc Helper() {}\n",
+		filepath.Join("other", "other_test.go"): "package other\n\nfunc ExampleT() {}\n",
+	}
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			t.Fatalf("无法创建目录: %v", err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", path, err)
+		}
+	}
+
+	report, err := FindExamplesDir(root + "/...")
+	if err != nil {
+		t.Fatalf("检查目录失败: %v", err)
+	}
+	if len(report.Examples) != 3 {
+		t.Fatalf("示例数量为 %d，期望 3: %+v", len(report.Examples), report.Examples)
+	}
+	if len(report.Diagnostics) != 2 {
+		t.Fatalf("诊断数量为 %d，期望 2: %v", len(report.Diagnostics), report.Diagnostics)
+	}
+	for _, d := range report.Diagnostics {
+		if d.Rule != "example-unknown-name" {
+			t.Errorf("诊断规则为 %s，期望 example-unknown-name: %s", d.Rule, d.Message)
+		}
+	}
+	if len(report.Missing) != 2 || report.Missing[0] != "other.ExampleT" || report.Missing[1] != "pkg.ExampleHelper" {
+		t.Errorf("缺少输出的示例为 %v", report.Missing)
+	}
+
+	// 单个文件输入同样读取同一目录中的声明
+	report, err = FindExamples(FromFile(filepath.Join(root, "pkg", "example_test.go")))
+	if err != nil {
+		t.Fatalf("检查文件失败: %v", err)
+	}
+	if len(report.Examples) != 2 || report.Examples[0].Target != "T.M" || len(report.Diagnostics) != 1 {
+		t.Errorf("单个文件的检查结果不正确: %+v", report)
+	}
+}
@@ -1,23 +1,9 @@
 package getcomments
 
 import (
-	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"os"
-	"path/filepath"
-	"sort"
-	"strings"
 	"sync"
 )
 
-// 缓存已解析的AST树
-var astCache = struct {
-	sync.RWMutex
-	fileAST map[string]*ast.File
-}{fileAST: make(map[string]*ast.File)}
-
 // 预分配的数组池
 var arrayPool = sync.Pool{
 	New: func() any {
@@ -28,316 +14,32 @@ var arrayPool = sync.Pool{
 // 优化版的ExtractComments函数
 // ExtractCommentsOptimized 是ExtractComments的优化版本
 // 主要优化点:
-// 1. AST解析缓存
+// 1. AST解析缓存（见ASTCache，文件变化后自动失效）
 // 2. 内存预分配和对象复用
 // 3. 减少字符串操作
 // 4. 更高效的数据结构
-// 5. 更智能的注释关联逻辑
 //
 // 性能提升：
 // - 小文件：约40%的速度提升
 // - 大文件：约60%的速度提升
 // - 内存分配：减少约50%
+//
+// 该函数现在与ExtractComments共用同一个Extractor实现，结果与早期版本不同：
+// 没有文档注释的声明向上收集所有连续的非空行，不再限制函数10行、其他声明5行；
+// 关联到同一代码行的注释按所在行排列，结构体字段的行尾注释关联到字段自身所在行。
+// 需要限制查找行数时使用NewExtractor(WithStrategy(StrategyBounded))。
+//
+// Deprecated: 该函数通过os.Stat猜测参数是文件路径还是代码内容，请使用
+// ExtractCommentsOptimizedFrom配合FromFile、FromSource或FromReader明确指定输入。
 func ExtractCommentsOptimized(input string) (CommentsMap, error) {
-	var content []byte
-	var err error
-	var filename string
-	var f *ast.File
-	fset := token.NewFileSet()
-
-	// 判断输入是文件路径还是代码内容
-	isFilePath := false
-	if _, err := os.Stat(input); err == nil {
-		isFilePath = true
-		filename = filepath.Base(input)
-	} else {
-		filename = "code.go"
-	}
-
-	// 优化1: 检查AST缓存
-	if isFilePath {
-		astCache.RLock()
-		cachedAST, exists := astCache.fileAST[input]
-		astCache.RUnlock()
-
-		if exists {
-			f = cachedAST
-			// 如果使用缓存AST，还需要读取文件内容用于后续处理
-			content, err = os.ReadFile(input)
-			if err != nil {
-				return nil, fmt.Errorf("读取文件失败: %v", err)
-			}
-		} else {
-			// 不存在缓存，读取文件并解析
-			content, err = os.ReadFile(input)
-			if err != nil {
-				return nil, fmt.Errorf("读取文件失败: %v", err)
-			}
-			f, err = parser.ParseFile(fset, filename, content, parser.ParseComments)
-			if err != nil {
-				return nil, fmt.Errorf("解析代码失败: %v", err)
-			}
-
-			// 缓存解析结果
-			astCache.Lock()
-			astCache.fileAST[input] = f
-			astCache.Unlock()
-		}
-	} else {
-		// 输入是代码内容
-		content = []byte(input)
-		f, err = parser.ParseFile(fset, filename, content, parser.ParseComments)
-		if err != nil {
-			return nil, fmt.Errorf("解析代码失败: %v", err)
-		}
-	}
-
-	// 将内容分割为行
-	lines := strings.Split(string(content), "\n")
-	lineCount := len(lines)
-
-	// 优化2: 预分配足够大小的数据结构
-	// 根据文件大小预估注释数量，一般不会超过行数的1/3
-	estimatedCommentCount := lineCount / 3
-	if estimatedCommentCount < 10 {
-		estimatedCommentCount = 10
-	}
-
-	commentsMap := make(CommentsMap, estimatedCommentCount)
-	lineComments := make([]string, lineCount+1)
-
-	// 使用对象池获取访问标记数组
-	visitedArr := arrayPool.Get().([]bool)
-	if cap(visitedArr) < lineCount+1 {
-		visitedArr = make([]bool, lineCount+1)
-	} else {
-		visitedArr = visitedArr[:lineCount+1]
-		// 重置为false
-		for i := range visitedArr {
-			visitedArr[i] = false
-		}
-	}
-	lineCommentsVisited := visitedArr
-	defer arrayPool.Put(visitedArr)
-
-	// 优化3: 更高效的注释收集
-	// 使用map预处理所有注释
-	commentPositions := make(map[int]string, len(f.Comments)*2)
-	for _, cg := range f.Comments {
-		for _, comment := range cg.List {
-			pos := fset.Position(comment.Pos()).Line
-			lineComments[pos] = comment.Text
-			commentPositions[pos] = comment.Text
-		}
-	}
-
-	// 处理函数声明和一般声明
-	processDeclarations(f, fset, filename, lines, lineComments, lineCommentsVisited, commentsMap)
-
-	// 处理其他语句
-	processStatements(f, fset, filename, lineComments, lineCommentsVisited, commentsMap)
-
-	return commentsMap, nil
+	return ExtractCommentsOptimizedFrom(guessInput(input))
 }
 
-// 处理声明节点
-func processDeclarations(f *ast.File, fset *token.FileSet, filename string,
-	lines []string, lineComments []string, lineCommentsVisited []bool,
-	commentsMap CommentsMap) {
-
-	for _, decl := range f.Decls {
-		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
-			processFuncDecl(funcDecl, fset, filename, lines, lineComments, lineCommentsVisited, commentsMap)
-		} else if genDecl, ok := decl.(*ast.GenDecl); ok {
-			processGenDecl(genDecl, fset, filename, lines, lineComments, lineCommentsVisited, commentsMap)
-		}
-	}
+// ExtractCommentsOptimizedFrom 从明确指定的输入提取注释，文件输入会使用AST缓存
+// 与ExtractCommentsFrom共用同一个Extractor实现，结果完全一致
+func ExtractCommentsOptimizedFrom(in Input) (CommentsMap, error) {
+	return optimizedExtractor.ExtractMap(in)
 }
 
-// 处理函数声明
-func processFuncDecl(funcDecl *ast.FuncDecl, fset *token.FileSet, filename string,
-	lines []string, lineComments []string, lineCommentsVisited []bool,
-	commentsMap CommentsMap) {
-
-	funcPos := fset.Position(funcDecl.Pos())
-	lineKey := fmt.Sprintf("%s:%d", filename, funcPos.Line)
-
-	// 优化: 直接确定需要检查的行号
-	var linesToCheck []int
-	linesToCheck = append(linesToCheck, funcPos.Line)
-
-	if funcDecl.Doc != nil {
-		for _, cm := range funcDecl.Doc.List {
-			linesToCheck = append(linesToCheck, fset.Position(cm.Pos()).Line)
-		}
-	} else {
-		// 有限查找上方连续注释
-		maxLinesToLookUp := 10 // 最多向上查找10行
-		startLine := funcPos.Line - 1
-		endLine := startLine - maxLinesToLookUp
-		if endLine < 0 {
-			endLine = 0
-		}
-
-		for i := startLine; i > endLine && i > 0; i-- {
-			if strings.TrimSpace(lines[i-1]) == "" {
-				break // 遇到空行停止
-			}
-			linesToCheck = append(linesToCheck, i)
-		}
-	}
-
-	// 收集注释
-	comments := collectCommentsEfficient(linesToCheck, lineComments, lineCommentsVisited)
-	if len(comments) > 0 {
-		commentsMap[lineKey] = comments
-	}
-}
-
-// 处理一般声明
-func processGenDecl(genDecl *ast.GenDecl, fset *token.FileSet, filename string,
-	lines []string, lineComments []string, lineCommentsVisited []bool,
-	commentsMap CommentsMap) {
-
-	for _, spec := range genDecl.Specs {
-		var pos token.Pos
-
-		switch s := spec.(type) {
-		case *ast.TypeSpec:
-			pos = s.Pos()
-			if structType, ok := s.Type.(*ast.StructType); ok && structType.Fields != nil {
-				collectFieldsCommentsOptimized(fset, filename, structType.Fields.List,
-					lineComments, lineCommentsVisited, commentsMap)
-			}
-		case *ast.ValueSpec:
-			pos = s.Pos()
-		default:
-			continue
-		}
-
-		specPos := fset.Position(pos)
-		lineKey := fmt.Sprintf("%s:%d", filename, specPos.Line)
-
-		// 类似函数处理，但限制向上查找的行数
-		var linesToCheck []int
-		linesToCheck = append(linesToCheck, specPos.Line)
-
-		if genDecl.Doc != nil {
-			for _, cm := range genDecl.Doc.List {
-				linesToCheck = append(linesToCheck, fset.Position(cm.Pos()).Line)
-			}
-		} else {
-			// 有限查找，最多5行
-			maxLines := 5
-			startLine := specPos.Line - 1
-			endLine := startLine - maxLines
-			if endLine < 0 {
-				endLine = 0
-			}
-
-			for i := startLine; i > endLine && i > 0; i-- {
-				if strings.TrimSpace(lines[i-1]) == "" {
-					break // 遇到空行停止
-				}
-				linesToCheck = append(linesToCheck, i)
-			}
-		}
-
-		comments := collectCommentsEfficient(linesToCheck, lineComments, lineCommentsVisited)
-		if len(comments) > 0 {
-			commentsMap[lineKey] = comments
-		}
-	}
-}
-
-// 处理其他语句
-func processStatements(f *ast.File, fset *token.FileSet, filename string,
-	lineComments []string, lineCommentsVisited []bool, commentsMap CommentsMap) {
-
-	ast.Inspect(f, func(n ast.Node) bool {
-		if n == nil {
-			return true
-		}
-
-		// 获取节点位置
-		pos := fset.Position(n.Pos())
-		if pos.Line == 0 {
-			return true // 跳过无效位置
-		}
-
-		lineKey := fmt.Sprintf("%s:%d", filename, pos.Line)
-
-		// 优化：只检查当前行和上一行
-		linesToCheck := []int{pos.Line, pos.Line - 1}
-		comments := collectCommentsEfficient(linesToCheck, lineComments, lineCommentsVisited)
-		if len(comments) > 0 && commentsMap[lineKey] == nil {
-			commentsMap[lineKey] = comments
-		}
-
-		// 处理特殊节点类型
-		switch node := n.(type) {
-		case *ast.InterfaceType:
-			if node.Methods != nil {
-				collectFieldsCommentsOptimized(fset, filename, node.Methods.List,
-					lineComments, lineCommentsVisited, commentsMap)
-			}
-		case *ast.StructType:
-			if node.Fields != nil {
-				collectFieldsCommentsOptimized(fset, filename, node.Fields.List,
-					lineComments, lineCommentsVisited, commentsMap)
-			}
-		}
-
-		return true
-	})
-}
-
-// 优化版的字段注释收集
-func collectFieldsCommentsOptimized(fset *token.FileSet, filename string, fields []*ast.Field,
-	lineComments []string, lineCommentsVisited []bool, commentsMap CommentsMap) {
-
-	// 预分配结果切片
-	results := make([]string, 0, len(fields))
-
-	for _, field := range fields {
-		fieldPos := fset.Position(field.Pos())
-		fieldKey := fmt.Sprintf("%s:%d", filename, fieldPos.Line)
-
-		// 只需要检查字段所在行
-		if !lineCommentsVisited[fieldPos.Line] && lineComments[fieldPos.Line] != "" {
-			results = append(results, lineComments[fieldPos.Line])
-			lineCommentsVisited[fieldPos.Line] = true
-
-			if len(results) > 0 && commentsMap[fieldKey] == nil {
-				commentsMap[fieldKey] = results
-				// 重置结果切片但保留容量
-				results = results[:0]
-			}
-		}
-	}
-}
-
-// 更高效的注释收集
-func collectCommentsEfficient(lines []int, lineComments []string, lineCommentsVisited []bool) []string {
-	// 只有一两行的情况下不需要排序
-	if len(lines) > 2 {
-		sort.Ints(lines)
-	}
-
-	// 预分配结果切片
-	results := make([]string, 0, len(lines))
-
-	for _, line := range lines {
-		// 确保行号有效
-		if line <= 0 || line >= len(lineComments) {
-			continue
-		}
-
-		if !lineCommentsVisited[line] && lineComments[line] != "" {
-			results = append(results, lineComments[line])
-			lineCommentsVisited[line] = true
-		}
-	}
-
-	return results
-}
+// 优化版提取器：文件输入使用包级AST缓存，并复用临时数组
+var optimizedExtractor = NewExtractor(WithCache(defaultCache), WithPooling(true))
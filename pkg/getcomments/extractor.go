@@ -0,0 +1,632 @@
+package getcomments
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"sort"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// AssociationStrategy 声明没有文档注释时，向上查找关联注释的策略
+type AssociationStrategy int
+
+const (
+	// StrategyContiguous 向上收集所有连续的非空行，直到遇到空行
+	StrategyContiguous AssociationStrategy = iota
+	// StrategyBounded 向上查找时限制行数：函数最多10行，其他声明最多5行
+	StrategyBounded
+)
+
+// 有限查找策略下向上查找的最大行数
+const (
+	maxFuncLookUp    = 10
+	maxGenDeclLookUp = 5
+)
+
+// Extractor 注释提取器
+// ExtractComments 与 ExtractCommentsOptimized 都是它的简单封装，
+// 两者共用同一套关联逻辑，只在缓存、对象复用等选项上有所不同，因此结果总是一致的
+type Extractor struct {
+	cache    *ASTCache
+	pooling  bool
+	strategy AssociationStrategy
+	filter   Filter
+	gen      generated.Mode
+	partial  bool
+}
+
+// Option 配置Extractor的选项
+type Option func(*Extractor)
+
+// WithCache 为文件输入启用AST缓存，cache 为nil时关闭缓存
+func WithCache(cache *ASTCache) Option {
+	return func(e *Extractor) {
+		e.cache = cache
+	}
+}
+
+// WithPooling 是否复用关联过程中的临时数组
+func WithPooling(enabled bool) Option {
+	return func(e *Extractor) {
+		e.pooling = enabled
+	}
+}
+
+// WithStrategy 设置注释关联策略，默认为StrategyContiguous
+func WithStrategy(strategy AssociationStrategy) Option {
+	return func(e *Extractor) {
+		e.strategy = strategy
+	}
+}
+
+// WithGenerated 设置生成文件（带有 "// Code generated ... DO NOT EDIT." 头）的处理方式：
+// generated.Skip 时Extract返回空结果，generated.Refuse 时返回包装了generated.ErrGenerated的错误。
+// 默认照常提取，生成文件的记录总是带有Generated标记
+func WithGenerated(mode generated.Mode) Option {
+	return func(e *Extractor) {
+		e.gen = mode
+	}
+}
+
+// NewExtractor 创建注释提取器，默认不使用缓存和对象复用
+func NewExtractor(opts ...Option) *Extractor {
+	e := &Extractor{strategy: StrategyContiguous}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// Extract 从输入提取结构化的注释记录
+func (e *Extractor) Extract(in Input) (CommentRecords, error) {
+	records, _, err := e.extract(context.Background(), in)
+	return records, err
+}
+
+// extract 提取注释记录，并返回输入是否是生成文件
+// 容错模式下存在语法错误时，返回部分结果与SyntaxErrors；
+// ctx 在读取之前、解析之后与关联注释前后检查，读取与解析过程中取消时立即返回ctx.Err()
+func (e *Extractor) extract(ctx context.Context, in Input) (CommentRecords, bool, error) {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, false, ctxErr
+	}
+	parsed, err := e.parseContext(ctx, in)
+	if _, ok := AsSyntaxErrors(err); err != nil && !ok {
+		return nil, false, err
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, false, ctxErr
+	}
+	isGenerated := generated.IsGenerated(parsed.File)
+	if skip, err := e.gen.Check(in.Filename(), isGenerated); skip || err != nil {
+		return nil, true, err
+	}
+	records := e.associate(in.Filename(), parsed)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, false, ctxErr
+	}
+	if isGenerated {
+		for i := range records {
+			records[i].Generated = true
+		}
+	}
+	return records, isGenerated, err
+}
+
+// parseContext 与parse相同，ctx 取消时不再等待读取与解析完成而是立即返回ctx.Err()
+// go/parser 无法中途停止，被放弃的解析在后台完成后结果被丢弃
+func (e *Extractor) parseContext(ctx context.Context, in Input) (*ParsedFile, error) {
+	if ctx.Done() == nil {
+		return e.parse(in)
+	}
+	type result struct {
+		parsed *ParsedFile
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		parsed, err := e.parse(in)
+		done <- result{parsed, err}
+	}()
+	select {
+	case r := <-done:
+		return r.parsed, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// ExtractMap 从输入提取注释并返回注释映射，容错模式下可能同时返回部分结果与SyntaxErrors
+func (e *Extractor) ExtractMap(in Input) (CommentsMap, error) {
+	records, err := e.Extract(in)
+	if _, ok := AsSyntaxErrors(err); err != nil && !ok {
+		return nil, err
+	}
+	return records.CommentsMap(), err
+}
+
+// parse 读取并解析输入，启用缓存时文件输入从缓存加载
+// 容错模式下代码存在语法错误时，同时返回部分结果与SyntaxErrors
+func (e *Extractor) parse(in Input) (*ParsedFile, error) {
+	if e.cache != nil && in.Path() != "" {
+		parsed, err := e.cache.Load(in.Path(), in.Filename())
+		if err == nil || !e.partial {
+			return parsed, err
+		}
+		// 有语法错误的文件不会被缓存，容错模式下重新解析以得到部分结果
+	}
+
+	content, err := in.load()
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, in.Filename(), content, parser.ParseComments)
+	if err != nil {
+		var list scanner.ErrorList
+		if !e.partial || !errors.As(err, &list) {
+			return nil, fmt.Errorf("解析代码失败: %v", err)
+		}
+		parsed := &ParsedFile{Fset: fset, File: f, Content: content, Errors: newSyntaxErrors(in.Filename(), list)}
+		return parsed, parsed.Errors
+	}
+	return &ParsedFile{Fset: fset, File: f, Content: content}, nil
+}
+
+// parseChecked 读取并解析输入，再按提取器的生成文件处理方式检查；skip 为true时调用者应返回空结果，
+// Refuse 方式下生成文件返回包装了generated.ErrGenerated的错误
+func (e *Extractor) parseChecked(in Input) (parsed *ParsedFile, skip bool, err error) {
+	if parsed, err = e.parse(in); err != nil {
+		return nil, false, err
+	}
+	if skip, err = e.gen.Check(in.Filename(), generated.IsGenerated(parsed.File)); skip || err != nil {
+		return nil, skip, err
+	}
+	return parsed, false, nil
+}
+
+// associate 将文件中的注释关联到代码行
+// 算法思路：
+// 1. 按行号索引所有注释，跨行的块注释出现在它覆盖的每一行，同一行的多条注释按出现顺序保存
+// 2. 处理函数声明与一般声明，收集文档注释及上方连续注释
+// 3. 遍历AST节点，收集当前行与上一行的注释
+// 4. 处理结构体字段与接口方法的行尾注释
+// 5. 容错模式下，按行关联第一个语法错误之后部分AST没有覆盖的注释
+func (e *Extractor) associate(filename string, parsed *ParsedFile) CommentRecords {
+	return e.associateBuilder(filename, parsed).records
+}
+
+// associateBuilder 关联注释并返回收集了记录的recordBuilder，需要记录所属节点时使用
+func (e *Extractor) associateBuilder(filename string, parsed *ParsedFile) *recordBuilder {
+	a, release := e.newAssociation(filename, parsed)
+	defer release()
+
+	// 处理函数声明和一般声明
+	a.processDeclarations()
+	// 处理其他语句
+	a.processStatements()
+	if len(parsed.Errors) > 0 {
+		a.processUnparsed(parsed.Errors[0].Pos.Line)
+	}
+
+	return a.builder
+}
+
+// newAssociation 按行索引文件中的注释，返回关联过程的状态，以及在关联结束后归还对象池资源的函数
+func (e *Extractor) newAssociation(filename string, parsed *ParsedFile) (*association, func()) {
+	fset, f := parsed.Fset, parsed.File
+
+	// 将内容分割为行
+	lines := strings.Split(string(parsed.Content), "\n")
+
+	a := &association{
+		fset:         fset,
+		file:         f,
+		lines:        lines,
+		strategy:     e.strategy,
+		builder:      newRecordBuilder(fset, filename, f, lines),
+		lineComments: make([][]indexedComment, len(lines)+1),
+	}
+	if !e.filter.IsZero() {
+		a.filter = newCommentFilter(e.filter, a.builder, f)
+	}
+
+	// 收集所有注释
+	count := 0
+	for _, cg := range f.Comments {
+		for _, comment := range cg.List {
+			c := indexedComment{node: comment, index: count}
+			count++
+			start, end := fset.Position(comment.Pos()).Line, fset.Position(comment.End()).Line
+			for line := start; line <= end && line < len(a.lineComments); line++ {
+				a.lineComments[line] = append(a.lineComments[line], c)
+			}
+		}
+	}
+
+	if e.pooling {
+		// 使用对象池获取访问标记数组
+		visitedArr := arrayPool.Get().([]bool)
+		if cap(visitedArr) < count {
+			visitedArr = make([]bool, count)
+		} else {
+			visitedArr = visitedArr[:count]
+			// 重置为false
+			for i := range visitedArr {
+				visitedArr[i] = false
+			}
+		}
+		a.commentVisited = visitedArr
+		return a, func() { arrayPool.Put(visitedArr) }
+	}
+	a.commentVisited = make([]bool, count)
+	return a, func() {}
+}
+
+// association 保存一次注释关联过程的状态
+type association struct {
+	fset           *token.FileSet
+	file           *ast.File
+	lines          []string
+	strategy       AssociationStrategy
+	builder        *recordBuilder
+	lineComments   [][]indexedComment // 行号 -> 覆盖该行的注释
+	commentVisited []bool             // 注释序号 -> 该注释是否已被关联
+	filter         *commentFilter     // 为nil时不过滤
+	decls          declVisitor        // 为nil时不通知
+}
+
+// declVisitor 接收声明处理过程访问到的声明与字段，文档覆盖率统计通过它复用同一次遍历
+// 只应在processDeclarations期间设置：processStatements同样会访问结构体与接口中的字段
+type declVisitor interface {
+	funcDecl(funcDecl *ast.FuncDecl)
+	spec(genDecl *ast.GenDecl, spec ast.Spec)
+	// field 的named为字段列表中具名字段的角色，owner与path与记录中的相同
+	field(field *ast.Field, named, owner OwnerKind, path string)
+}
+
+// indexedComment 带有文件内序号的注释，序号用于标记注释是否已被关联
+type indexedComment struct {
+	node  *ast.Comment
+	index int
+}
+
+// processDeclarations 处理顶层的函数声明和一般声明
+func (a *association) processDeclarations() {
+	for _, decl := range a.file.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
+			a.processFuncDecl(funcDecl)
+		} else if genDecl, ok := decl.(*ast.GenDecl); ok {
+			a.processGenDecl(genDecl)
+		}
+	}
+}
+
+// processFuncDecl 处理函数声明：文档注释、上方连续注释以及声明行的行尾注释
+func (a *association) processFuncDecl(funcDecl *ast.FuncDecl) {
+	if a.decls != nil {
+		a.decls.funcDecl(funcDecl)
+	}
+	funcPos := a.fset.Position(funcDecl.Pos())
+	// 收集本行的文档注释
+	currentLines := []int{funcPos.Line}
+	// 先尝试doc注释
+	if funcDecl.Doc != nil {
+		currentLines = append(currentLines, a.commentGroupLines(funcDecl.Doc)...)
+	} else {
+		// 尝试查找上方连续的行注释
+		currentLines = append(currentLines, a.lookUp(funcPos.Line, maxFuncLookUp)...)
+	}
+	comments := a.collectCurrentLineComments(currentLines)
+	// 去重并存储
+	a.builder.add(funcPos.Line, funcDecl, comments)
+	if funcDecl.Type.TypeParams != nil {
+		a.collectFieldsComments(funcDecl.Type.TypeParams.List, OwnerTypeParam, "")
+	}
+}
+
+// processGenDecl 处理常量、变量和类型声明，以及类型参数、结构体字段与接口方法的注释
+func (a *association) processGenDecl(genDecl *ast.GenDecl) {
+	for _, spec := range genDecl.Specs {
+		var pos token.Pos
+
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			pos = s.Pos()
+		case *ast.ValueSpec:
+			pos = s.Pos()
+		default:
+			continue
+		}
+
+		specPos := a.fset.Position(pos)
+		currentLines := []int{specPos.Line}
+
+		// 尝试使用Doc注释
+		if genDecl.Doc != nil {
+			currentLines = append(currentLines, a.commentGroupLines(genDecl.Doc)...)
+		} else {
+			// 尝试查找上方连续的行注释
+			currentLines = append(currentLines, a.lookUp(specPos.Line, maxGenDeclLookUp)...)
+		}
+		comments := a.collectCurrentLineComments(currentLines)
+		// 去重并存储
+		a.builder.add(specPos.Line, spec, comments)
+		if a.decls != nil {
+			a.decls.spec(genDecl, spec)
+		}
+
+		// 处理类型参数，以及结构体（包括变量的匿名结构体类型）与接口中的字段
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			if s.TypeParams != nil {
+				a.collectFieldsComments(s.TypeParams.List, OwnerTypeParam, "")
+			}
+			a.collectTypeComments(s.Type, "")
+		case *ast.ValueSpec:
+			a.collectTypeComments(s.Type, "")
+		}
+	}
+}
+
+// processStatements 遍历所有节点，关联当前行与上一行的注释
+// 结束于上一行的跨行块注释同样关联到当前行
+func (a *association) processStatements() {
+	ast.Inspect(a.file, func(n ast.Node) bool {
+		if n == nil {
+			return true
+		}
+
+		// 获取节点位置
+		pos := a.fset.Position(n.Pos())
+		if pos.Line == 0 {
+			return true // 跳过无效位置
+		}
+
+		// 收集当前行和上一行的注释
+		comments := a.collectCurrentLineComments([]int{pos.Line, pos.Line - 1})
+		// 去重并存储
+		a.builder.add(pos.Line, n, comments)
+
+		// 处理特殊节点类型
+		switch node := n.(type) {
+		case *ast.InterfaceType, *ast.StructType:
+			a.collectTypeComments(node.(ast.Expr), "")
+		}
+
+		return true
+	})
+}
+
+// processUnparsed 关联从第from行开始仍未关联的注释：行尾注释关联到所在行，
+// 独立的注释关联到下方第一个代码行，与代码之间隔着空行时关联到注释自身所在行
+func (a *association) processUnparsed(from int) {
+	var targets []int
+	pending := make(map[int][]comment)
+	for line := from; line < len(a.lineComments); line++ {
+		for _, c := range a.lineComments[line] {
+			start := a.fset.Position(c.node.Pos())
+			if start.Line != line || a.commentVisited[c.index] || !a.accept(c.node) {
+				continue
+			}
+			a.commentVisited[c.index] = true
+			target := start.Line
+			if onlyBlockComments(a.lines[start.Line-1][:start.Column-1]) {
+				target = a.nextCodeLine(a.fset.Position(c.node.End()).Line, start.Line)
+			}
+			if pending[target] == nil {
+				targets = append(targets, target)
+			}
+			pending[target] = append(pending[target], comment{line: start.Line, content: c.node.Text, node: c.node})
+		}
+	}
+	for _, target := range targets {
+		pos := a.file.End()
+		if file := a.fset.File(a.file.Pos()); file != nil && target <= file.LineCount() {
+			pos = file.LineStart(target)
+		}
+		a.builder.add(target, &ast.BadDecl{From: pos, To: pos}, pending[target])
+	}
+}
+
+// nextCodeLine 返回第line行之后第一个既不是空行也不是注释的行，先遇到空行或文件结束时返回fallback
+func (a *association) nextCodeLine(line, fallback int) int {
+	for i := line + 1; i <= len(a.lines); i++ {
+		text := strings.TrimSpace(a.lines[i-1])
+		switch {
+		case text == "":
+			return fallback
+		case strings.HasPrefix(text, "//") || strings.HasPrefix(text, "/*"):
+			continue
+		}
+		return i
+	}
+	return fallback
+}
+
+// commentGroupLines 返回注释组中每条注释的起始行
+func (a *association) commentGroupLines(cg *ast.CommentGroup) []int {
+	lines := make([]int, 0, len(cg.List))
+	for _, cm := range cg.List {
+		lines = append(lines, a.fset.Position(cm.Pos()).Line)
+	}
+	return lines
+}
+
+// lookUp 从line的上一行开始向上查找连续的非空行
+// StrategyBounded 策略下最多查找limit行
+func (a *association) lookUp(line, limit int) []int {
+	var result []int
+	for i := line - 1; i > 0; i-- {
+		if a.strategy == StrategyBounded && len(result) >= limit {
+			break
+		}
+		if strings.TrimSpace(a.lines[i-1]) == "" {
+			break // 遇到空行停止
+		}
+		result = append(result, i)
+	}
+	return result
+}
+
+// accept 判断注释是否通过过滤条件，未通过的注释不会被标记为已访问
+func (a *association) accept(c *ast.Comment) bool {
+	return a.filter == nil || a.filter.accept(c)
+}
+
+// collectFieldsComments 评估正确性：
+// 1. 正确性验证：
+// - 正确遍历结构体/接口字段与类型参数
+// - 准确获取字段位置信息
+// - 正确收集字段关联注释
+// - 避免重复收集已处理注释
+//
+// 算法思路：
+// 1. 遍历所有字段节点
+// 2. 获取每个字段的位置信息
+// 3. 收集字段所在行的注释与文档注释，没有文档注释时（如类型参数）收集上方连续的注释行
+// 4. 去重后存入结果映射，记录字段的角色与路径
+// 5. 递归处理匿名结构体与接口类型中的字段
+//
+// named 为列表中具名字段的角色：结构体为OwnerField，接口为OwnerMethod，类型参数为OwnerTypeParam；
+// path 为外层字段的路径
+//
+// 时间复杂度分析：
+// - 字段遍历：O(n) n为字段数量
+// - 位置获取：O(1)
+// - 注释收集：O(1) 因使用预计算的lineComments
+// 总体复杂度：O(n)
+//
+// 优化建议：
+// 1. 批量预计算字段位置信息
+// 2. 并行处理字段注释收集
+// 3. 添加字段注释类型过滤（如只收集文档注释）
+// 4. 优化内存分配，预分配结果切片
+// 5. 添加字段名称匹配过滤选项
+func (a *association) collectFieldsComments(fields []*ast.Field, named OwnerKind, path string) {
+	for _, field := range fields {
+		owner, fieldPath := named, path
+		if len(field.Names) > 0 {
+			fieldPath = joinPath(path, field.Names[0].Name)
+		} else if ident := embeddedFieldIdent(field.Type); ident != nil {
+			owner, fieldPath = OwnerEmbedded, joinPath(path, ident.Name)
+		} else {
+			owner = OwnerTypeSet
+		}
+
+		fieldPos := a.fset.Position(field.Pos())
+		currentLines := []int{fieldPos.Line}
+		if field.Doc != nil {
+			currentLines = append(currentLines, a.commentGroupLines(field.Doc)...)
+		} else {
+			currentLines = append(currentLines, a.commentLinesAbove(fieldPos.Line)...)
+		}
+		fieldComments := a.collectCurrentLineComments(currentLines)
+		// 去重并存储
+		a.builder.addField(fieldPos.Line, field, owner, fieldPath, fieldComments)
+		if a.decls != nil {
+			a.decls.field(field, named, owner, fieldPath)
+		}
+
+		if owner != OwnerTypeSet {
+			a.collectTypeComments(field.Type, fieldPath)
+		}
+	}
+}
+
+// collectTypeComments 处理匿名结构体与接口类型中的字段，指针、切片、数组与映射的元素类型同样处理
+func (a *association) collectTypeComments(expr ast.Expr, path string) {
+	switch t := expr.(type) {
+	case *ast.StructType:
+		if t.Fields != nil {
+			a.collectFieldsComments(t.Fields.List, OwnerField, path)
+		}
+	case *ast.InterfaceType:
+		if t.Methods != nil {
+			a.collectFieldsComments(t.Methods.List, OwnerMethod, path)
+		}
+	case *ast.StarExpr:
+		a.collectTypeComments(t.X, path)
+	case *ast.ArrayType:
+		a.collectTypeComments(t.Elt, path)
+	case *ast.MapType:
+		a.collectTypeComments(t.Value, path)
+	}
+}
+
+// commentLinesAbove 从line的上一行开始向上查找连续的只有注释的行，遇到空行或代码行停止
+func (a *association) commentLinesAbove(line int) []int {
+	var result []int
+	for i := line - 1; i > 0 && i <= len(a.lines); i-- {
+		text := strings.TrimSpace(a.lines[i-1])
+		if !strings.HasPrefix(text, "//") && !(strings.HasPrefix(text, "/*") && onlyBlockComments(text)) {
+			break
+		}
+		result = append(result, i)
+	}
+	return result
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// collectCurrentLineComments 评估正确性：
+// 1. 正确性验证：
+// - 正确排序输入行号
+// - 准确过滤已访问注释行
+// - 正确收集非空注释内容
+// - 维护已访问状态避免重复收集
+//
+// 算法思路：
+// 1. 对输入行号进行排序
+// 2. 预分配结果切片容量
+// 3. 遍历所有行号
+// 4. 检查行注释是否有效且未访问
+// 5. 收集有效注释并标记已访问
+//
+// 时间复杂度分析：
+// - 行号排序：O(n log n) n为输入行数
+// - 行遍历：O(n)
+// - 注释检查：O(1)
+// 总体复杂度：O(n log n)
+//
+// 优化建议：
+// 1. 如果输入行号已排序可跳过排序步骤
+// 2. 使用位图替代bool切片减少内存占用
+// 3. 并行处理行号遍历（需解决数据竞争）
+// 4. 预计算有效注释行减少运行时检查
+// 5. 添加注释内容过滤选项（如只收集特定前缀注释）
+func (a *association) collectCurrentLineComments(lines []int) []comment {
+	// 只有一两行的情况下不需要排序
+	if len(lines) > 2 {
+		sort.Ints(lines)
+	} else if len(lines) == 2 && lines[0] > lines[1] {
+		lines[0], lines[1] = lines[1], lines[0]
+	}
+
+	comments := make([]comment, 0, len(lines))
+	for _, line := range lines {
+		// 确保行号有效
+		if line <= 0 || line >= len(a.lineComments) {
+			continue
+		}
+		for _, c := range a.lineComments[line] {
+			if !a.commentVisited[c.index] && a.accept(c.node) {
+				comments = append(comments, comment{line: a.fset.Position(c.node.Pos()).Line, content: c.node.Text, node: c.node})
+				a.commentVisited[c.index] = true
+			}
+		}
+	}
+	return comments
+}
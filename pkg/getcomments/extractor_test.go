@@ -0,0 +1,184 @@
+package getcomments
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "使用当前实现重新生成 testdata/golden 中的golden文件")
+
+// goldenStrategies 每种关联策略及其golden文件的后缀
+var goldenStrategies = []struct {
+	name     string
+	strategy AssociationStrategy
+	suffix   string
+}{
+	{"contiguous", StrategyContiguous, ".json"},
+	{"bounded", StrategyBounded, ".bounded.json"},
+}
+
+// TestExtractorGolden 将 testdata 中每个文件在每种关联策略下的提取结果与 testdata/golden 中的预期输出比较，
+// 缺少golden文件时失败，使用 go test -run TestExtractorGolden -update 生成。
+// 重构前就存在的文件的默认策略golden文件由统一为Extractor之前的ExtractComments（基线提交 d90d0c7）生成，
+// 此后只更新过有意改变的关联：接口方法的多行文档注释整体关联到方法所在行。
+// 重构前的ExtractCommentsOptimized在这些文件上与ExtractComments并不一致（注释顺序不同，
+// 字段的行尾注释会关联到其他字段），不作为预期输出
+func TestExtractorGolden(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("testdata", "*.go"))
+	if err != nil {
+		t.Fatalf("查找测试文件失败: %v", err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("testdata 中没有测试文件")
+	}
+
+	for _, file := range files {
+		for _, s := range goldenStrategies {
+			t.Run(filepath.Base(file)+"/"+s.name, func(t *testing.T) {
+				golden := filepath.Join("testdata", "golden", filepath.Base(file)+s.suffix)
+				if *updateGolden {
+					writeGolden(t, golden, NewExtractor(WithStrategy(s.strategy)), file)
+				}
+				content, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("缺少golden文件 %s，使用 -update 生成: %v", golden, err)
+				}
+				var want CommentsMap
+				if err := json.Unmarshal(content, &want); err != nil {
+					t.Fatalf("解析golden文件失败: %v", err)
+				}
+
+				check := func(name string, got CommentsMap, err error) {
+					t.Helper()
+					if err != nil {
+						t.Fatalf("%s出错: %v", name, err)
+					}
+					if !reflect.DeepEqual(got, want) {
+						for key, comments := range want {
+							if !reflect.DeepEqual(got[key], comments) {
+								t.Errorf("%s: %s 的注释为 %q，期望 %q", name, key, got[key], comments)
+							}
+						}
+						for key, comments := range got {
+							if _, ok := want[key]; !ok {
+								t.Errorf("%s: 多出了 %s 的注释 %q", name, key, comments)
+							}
+						}
+					}
+				}
+
+				if s.strategy == StrategyContiguous {
+					got, err := ExtractCommentsFrom(FromFile(file))
+					check("ExtractCommentsFrom", got, err)
+					// 调用两次，第二次命中缓存
+					for i := 0; i < 2; i++ {
+						got, err := ExtractCommentsOptimizedFrom(FromFile(file))
+						check("ExtractCommentsOptimizedFrom", got, err)
+					}
+				}
+				for _, cache := range []*ASTCache{nil, NewASTCache(CacheOptions{})} {
+					for _, pooling := range []bool{false, true} {
+						e := NewExtractor(WithStrategy(s.strategy), WithCache(cache), WithPooling(pooling))
+						for i := 0; i < 2; i++ {
+							got, err := e.ExtractMap(FromFile(file))
+							check(fmt.Sprintf("缓存 %v 复用 %v 第%d次调用", cache != nil, pooling, i+1), got, err)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+// writeGolden 使用提取器提取文件的注释，写入golden文件
+func writeGolden(t *testing.T, golden string, e *Extractor, file string) {
+	t.Helper()
+	comments, err := e.ExtractMap(FromFile(file))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	content, err := json.MarshalIndent(comments, "", "\t")
+	if err != nil {
+		t.Fatalf("序列化注释失败: %v", err)
+	}
+	if err := os.WriteFile(golden, append(content, '\n'), 0644); err != nil {
+		t.Fatalf("写入golden文件失败: %v", err)
+	}
+}
+
+func TestExtractorStrategy(t *testing.T) {
+	code := `package p
+
+var (
+	// 第1行
+	// 第2行
+	// 第3行
+	// 第4行
+	// 第5行
+	// 第6行
+	// 第7行
+	A = 1
+)
+`
+	in := FromSource("p.go", []byte(code))
+
+	contiguous, err := NewExtractor().ExtractMap(in)
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	if got := len(contiguous["p.go:11"]); got != 7 {
+		t.Errorf("连续查找策略收集到 %d 条注释, 期望 7", got)
+	}
+
+	bounded, err := NewExtractor(WithStrategy(StrategyBounded)).ExtractMap(in)
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	if got := bounded["p.go:11"]; len(got) != 5 || got[0] != "// 第3行" {
+		t.Errorf("有限查找策略收集到 %v, 期望第3行到第7行", got)
+	}
+}
+
+// TestExtractCommentShapes 跨行块注释与同一行多条注释的回归测试
+func TestExtractCommentShapes(t *testing.T) {
+	records, err := ExtractCommentRecordsFrom(FromFile(filepath.Join("testdata", "comment_shapes.go")))
+	if err != nil {
+		t.Fatalf("提取注释记录失败: %v", err)
+	}
+
+	want := CommentsMap{
+		"comment_shapes.go:10": {"/*\nCommentShapes 跨行的块注释作为文档\n\n空行也属于同一条注释\n*/"},
+		"comment_shapes.go:13": {"/* 跨行块注释\n\t   结束于语句的上一行 */"},
+		"comment_shapes.go:15": {"/* 结束于语句所在行\n\t */"},
+		"comment_shapes.go:16": {"/* 块注释 */", "// 同一行的第二条注释"},
+		"comment_shapes.go:18": {"/* 第一条 */", "/* 第二条 */"},
+		"comment_shapes.go:27": {"// Shape 字段的多条注释"},
+		"comment_shapes.go:28": {"/* 名称 */", "// 显示名称"},
+		"comment_shapes.go:29": {"// 大小"},
+		"comment_shapes.go:32": {"/* 颜色\n\t   十六进制 */"},
+	}
+	if got := records.CommentsMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("注释映射为\n%v\n期望\n%v", got, want)
+	}
+
+	kinds := make(map[string]CommentKind)
+	for _, r := range records {
+		kinds[r.Text] = r.Kind
+	}
+	for text, kind := range map[string]CommentKind{
+		"结束于语句所在行": KindLeading,
+		"块注释":      KindTrailing,
+		"第二条":      KindLeading,
+		"名称":       KindTrailing,
+		"颜色\n十六进制": KindDoc,
+	} {
+		if kinds[text] != kind {
+			t.Errorf("注释 %q 的类型为 %q, 期望 %q", text, kinds[text], kind)
+		}
+	}
+}
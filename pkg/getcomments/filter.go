@@ -0,0 +1,134 @@
+package getcomments
+
+import (
+	"go/ast"
+	"go/build/constraint"
+	"go/token"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Filter 注释过滤选项，零值表示不过滤
+// 过滤在关联阶段进行：被排除的注释不会被标记为已访问，也就不会占用代码行的关联位置
+type Filter struct {
+	DocOnly           bool           // 只保留文档注释
+	TrailingOnly      bool           // 只保留行尾注释
+	InlineOnly        bool           // 只保留函数体内部的注释
+	ExcludeDirectives bool           // 排除 //go:generate、//nolint 等编译器与检查工具指令
+	Include           *regexp.Regexp // 清洗后的注释文本必须匹配
+	Exclude           *regexp.Regexp // 清洗后的注释文本匹配时排除
+	MinLength         int            // 清洗后的注释文本最少字符数
+}
+
+// WithFilter 设置注释过滤选项
+// DocOnly、TrailingOnly、InlineOnly 同时设置时，满足其中任意一项即可保留
+func WithFilter(filter Filter) Option {
+	return func(e *Extractor) {
+		e.filter = filter
+	}
+}
+
+// IsZero 判断是否没有设置任何过滤条件
+func (f Filter) IsZero() bool {
+	return !f.DocOnly && !f.TrailingOnly && !f.InlineOnly && !f.ExcludeDirectives &&
+		f.Include == nil && f.Exclude == nil && f.MinLength <= 0
+}
+
+// commentFilter 在一次关联过程中判断注释是否保留
+type commentFilter struct {
+	Filter
+	builder *recordBuilder
+	bodies  [][2]token.Pos // 函数体的范围，用于判断函数体内部的注释
+}
+
+func newCommentFilter(filter Filter, builder *recordBuilder, f *ast.File) *commentFilter {
+	cf := &commentFilter{Filter: filter, builder: builder}
+	if filter.InlineOnly {
+		ast.Inspect(f, func(n ast.Node) bool {
+			var body *ast.BlockStmt
+			switch node := n.(type) {
+			case *ast.FuncDecl:
+				body = node.Body
+			case *ast.FuncLit:
+				body = node.Body
+			}
+			if body != nil {
+				cf.bodies = append(cf.bodies, [2]token.Pos{body.Lbrace, body.Rbrace})
+			}
+			return true
+		})
+	}
+	return cf
+}
+
+// accept 判断注释是否通过过滤条件
+func (cf *commentFilter) accept(c *ast.Comment) bool {
+	if cf.DocOnly || cf.TrailingOnly || cf.InlineOnly {
+		kind := cf.builder.kindOf(c, cf.builder.fset.Position(c.Pos()))
+		if !(cf.DocOnly && kind == KindDoc ||
+			cf.TrailingOnly && kind == KindTrailing ||
+			cf.InlineOnly && cf.inBody(c.Pos())) {
+			return false
+		}
+	}
+	if cf.ExcludeDirectives && IsDirective(c.Text) {
+		return false
+	}
+
+	text := cleanCommentText(c.Text)
+	if cf.MinLength > 0 && utf8.RuneCountInString(text) < cf.MinLength {
+		return false
+	}
+	if cf.Include != nil && !cf.Include.MatchString(text) {
+		return false
+	}
+	if cf.Exclude != nil && cf.Exclude.MatchString(text) {
+		return false
+	}
+	return true
+}
+
+// inBody 判断位置是否在某个函数体内部
+func (cf *commentFilter) inBody(pos token.Pos) bool {
+	for _, body := range cf.bodies {
+		if pos > body[0] && pos < body[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDirective 判断原始注释文本是否为编译器或检查工具指令
+// 包括 //go:build、//go:generate 等 "//名称:参数" 形式的指令，//line、//export、//extern，
+// 以及检查工具与构建约束的指令：//nolint（后面为结尾、冒号或空格，与golangci-lint相同，注释符号后不能有空格）、
+// //lint:ignore 等 //lint: 指令和 // +build 约束。"// nolint 不推荐" 这样的普通说明不是指令
+func IsDirective(raw string) bool {
+	if !strings.HasPrefix(raw, "//") {
+		return false
+	}
+	text := raw[2:]
+	for _, prefix := range []string{"line ", "export ", "extern "} {
+		if strings.HasPrefix(text, prefix) {
+			return true
+		}
+	}
+	// 与go/ast相同的规则：//后紧跟[a-z0-9]+:[a-z0-9]
+	colon := strings.Index(text, ":")
+	if colon > 0 && colon+1 < len(text) && isDirectiveWord(text[:colon]) && isDirectiveWord(text[colon+1:colon+2]) {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(text, "nolint"); ok && (rest == "" || rest[0] == ':' || rest[0] == ' ') {
+		return true
+	}
+	return strings.HasPrefix(text, "lint:") || constraint.IsPlusBuild(raw)
+}
+
+func isDirectiveWord(s string) bool {
+	for _, r := range s {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9') {
+			return false
+		}
+	}
+	return s != ""
+}
@@ -0,0 +1,132 @@
+package getcomments
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+const filterTestCode = `// Copyright 2024 示例作者. 保留所有权利.
+package p
+
+//go:generate stringer -type=Color
+// Color 颜色
+type Color int
+
+// Paint 上色
+func Paint() { // 行尾注释Paint
+	// 函数体内的注释
+	x := 1 //nolint:ineffassign
+	// TODO: 处理y
+	y := 2
+	_ = func() {
+		// 闭包内的注释
+		println(x)
+	}
+	_, _ = x, y
+}
+`
+
+func TestExtractorFilter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		filter Filter
+		want   CommentsMap
+	}{
+		{
+			name:   "只保留文档注释",
+			filter: Filter{DocOnly: true},
+			want: CommentsMap{
+				"p.go:2": {"// Copyright 2024 示例作者. 保留所有权利."},
+				"p.go:6": {"//go:generate stringer -type=Color", "// Color 颜色"},
+				"p.go:9": {"// Paint 上色"},
+			},
+		},
+		{
+			name:   "文档注释中排除指令",
+			filter: Filter{DocOnly: true, ExcludeDirectives: true},
+			want: CommentsMap{
+				"p.go:2": {"// Copyright 2024 示例作者. 保留所有权利."},
+				"p.go:6": {"// Color 颜色"},
+				"p.go:9": {"// Paint 上色"},
+			},
+		},
+		{
+			name:   "只保留行尾注释",
+			filter: Filter{TrailingOnly: true},
+			want: CommentsMap{
+				"p.go:9":  {"// 行尾注释Paint"},
+				"p.go:11": {"//nolint:ineffassign"},
+			},
+		},
+		{
+			name:   "只保留函数体内的注释并排除指令",
+			filter: Filter{InlineOnly: true, ExcludeDirectives: true},
+			want: CommentsMap{
+				"p.go:9":  {"// 行尾注释Paint"},
+				"p.go:11": {"// 函数体内的注释"},
+				"p.go:13": {"// TODO: 处理y"},
+				"p.go:16": {"// 闭包内的注释"},
+			},
+		},
+		{
+			name: "正则过滤与最小长度",
+			filter: Filter{
+				Exclude:   regexp.MustCompile(`(?i)copyright`),
+				Include:   regexp.MustCompile(`注释|TODO`),
+				MinLength: 7,
+			},
+			want: CommentsMap{
+				"p.go:9":  {"// 行尾注释Paint"},
+				"p.go:11": {"// 函数体内的注释"},
+				"p.go:13": {"// TODO: 处理y"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewExtractor(WithFilter(tc.filter)).ExtractMap(FromSource("p.go", []byte(filterTestCode)))
+			if err != nil {
+				t.Fatalf("提取注释失败: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("结果为 %v, 期望 %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIsDirective(t *testing.T) {
+	testCases := []struct {
+		raw  string
+		want bool
+	}{
+		{"//go:generate stringer -type=Color", true},
+		{"//go:build linux", true},
+		{"//go:embed static/*", true},
+		{"// +build linux", true},
+		{"//nolint:errcheck", true},
+		{"//nolint", true},
+		{"//nolint // 说明", true},
+		{"//lint:ignore SA1019 兼容旧接口", true},
+		{"//lint:file-ignore U1000 未使用", true},
+		{"//line foo.go:10", true},
+		{"//export Foo", true},
+		{"// go:generate 这只是说明", false},
+		{"// TODO: 处理y", false},
+		{"// 注释: 说明", false},
+		{"/* go:generate */", false},
+		// 检查工具指令只有固定的形式，提到这些词的普通说明不是指令
+		{"// nolint", false},
+		{"// nolint is discouraged here", false},
+		{"//nolintable", false},
+		{"// lint: 说明", false},
+		{"// +builder 模式", false},
+	}
+	for _, tc := range testCases {
+		if got := IsDirective(tc.raw); got != tc.want {
+			t.Errorf("IsDirective(%q) = %v, 期望 %v", tc.raw, got, tc.want)
+		}
+	}
+}
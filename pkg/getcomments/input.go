@@ -0,0 +1,72 @@
+package getcomments
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Input 描述一份待提取注释的Go源代码
+// 通过FromFile、FromSource、FromReader明确指定输入来源，
+// 不再根据os.Stat猜测参数是文件路径还是代码内容
+type Input struct {
+	path     string
+	filename string
+	src      []byte
+	reader   io.Reader
+}
+
+// FromFile 以文件路径作为输入，记录中的文件名为路径的最后一个元素
+// 文件不存在时，提取会返回读取文件失败的错误
+func FromFile(path string) Input {
+	return Input{path: path, filename: filepath.Base(path)}
+}
+
+// FromSource 以源代码内容作为输入，filename 为调用方指定的文件名
+func FromSource(filename string, src []byte) Input {
+	return Input{filename: filename, src: src}
+}
+
+// FromReader 以io.Reader作为输入，内容在提取时一次性读取，filename 为调用方指定的文件名
+func FromReader(filename string, r io.Reader) Input {
+	return Input{filename: filename, reader: r}
+}
+
+// Filename 返回记录中使用的文件名
+func (in Input) Filename() string {
+	return in.filename
+}
+
+// Path 返回文件路径，非文件输入时为空
+func (in Input) Path() string {
+	return in.path
+}
+
+// load 读取输入的源代码内容
+func (in Input) load() ([]byte, error) {
+	switch {
+	case in.path != "":
+		content, err := os.ReadFile(in.path)
+		if err != nil {
+			return nil, fmt.Errorf("读取文件失败: %v", err)
+		}
+		return content, nil
+	case in.reader != nil:
+		content, err := io.ReadAll(in.reader)
+		if err != nil {
+			return nil, fmt.Errorf("读取输入失败: %v", err)
+		}
+		return content, nil
+	default:
+		return in.src, nil
+	}
+}
+
+// guessInput 保留旧接口的判断方式：路径存在时作为文件，否则作为代码内容
+func guessInput(input string) Input {
+	if _, err := os.Stat(input); err == nil {
+		return FromFile(input)
+	}
+	return FromSource("code.go", []byte(input))
+}
@@ -0,0 +1,76 @@
+package getcomments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("读取中断")
+}
+
+func TestExtractCommentsFrom(t *testing.T) {
+	exampleFile := filepath.Join("testdata", "examples.go")
+	content, err := os.ReadFile(exampleFile)
+	if err != nil {
+		t.Fatalf("读取文件失败: %v", err)
+	}
+
+	fromFile, err := ExtractCommentsFrom(FromFile(exampleFile))
+	if err != nil {
+		t.Fatalf("从文件提取注释失败: %v", err)
+	}
+	fromSource, err := ExtractCommentsFrom(FromSource("examples.go", content))
+	if err != nil {
+		t.Fatalf("从源代码提取注释失败: %v", err)
+	}
+	fromReader, err := ExtractCommentsFrom(FromReader("examples.go", strings.NewReader(string(content))))
+	if err != nil {
+		t.Fatalf("从Reader提取注释失败: %v", err)
+	}
+
+	if !reflect.DeepEqual(fromFile, fromSource) || !reflect.DeepEqual(fromFile, fromReader) {
+		t.Errorf("三种输入方式的结果不一致")
+	}
+	if _, ok := fromFile["examples.go:11"]; !ok {
+		t.Errorf("未找到预期的键 examples.go:11")
+	}
+
+	// 旧接口仍然可用
+	legacy, err := ExtractComments(exampleFile)
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	if !reflect.DeepEqual(fromFile, legacy) {
+		t.Errorf("ExtractComments 与 ExtractCommentsFrom 的结果不一致")
+	}
+}
+
+func TestExtractCommentsFromErrors(t *testing.T) {
+	// 路径拼写错误时应报告读取失败，而不是当作代码解析
+	_, err := ExtractCommentsFrom(FromFile(filepath.Join("testdata", "exmaples.go")))
+	if err == nil || !strings.Contains(err.Error(), "读取文件失败") {
+		t.Errorf("期望读取文件失败的错误, 得到 %v", err)
+	}
+
+	_, err = ExtractCommentsOptimizedFrom(FromFile(filepath.Join("testdata", "exmaples.go")))
+	if err == nil || !strings.Contains(err.Error(), "读取文件失败") {
+		t.Errorf("期望读取文件失败的错误, 得到 %v", err)
+	}
+
+	_, err = ExtractCommentsFrom(FromReader("stdin.go", failingReader{}))
+	if err == nil || !strings.Contains(err.Error(), "读取输入失败") {
+		t.Errorf("期望读取输入失败的错误, 得到 %v", err)
+	}
+
+	_, err = ExtractCommentsFrom(FromSource("bad.go", []byte("package p\nfunc (")))
+	if err == nil || !strings.Contains(err.Error(), "解析代码失败") {
+		t.Errorf("期望解析代码失败的错误, 得到 %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package getcomments
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Keys 返回按文件名与行号排列的键
+func (m CommentsMap) Keys() []string {
+	return sortedKeys(m)
+}
+
+// MarshalJSON 按Keys的顺序输出键，使结果与代码中的顺序一致且稳定
+// encoding/json 默认按字符串排序，"main.go:10" 会排在 "main.go:9" 之前
+func (m CommentsMap) MarshalJSON() ([]byte, error) {
+	return marshalOrdered(m)
+}
+
+// Keys 返回按文件名与行号排列的键，按符号分组时按符号排列
+func (m GroupedCommentsMap) Keys() []string {
+	return sortedKeys(m)
+}
+
+// MarshalJSON 按Keys的顺序输出键
+func (m GroupedCommentsMap) MarshalJSON() ([]byte, error) {
+	return marshalOrdered(m)
+}
+
+// Sort 将记录按文件名与注释的起始位置排列
+// 提取得到的记录先是声明的注释，然后是语句的注释，需要按代码顺序输出时先排序
+func (rs CommentRecords) Sort() {
+	sort.SliceStable(rs, func(i, j int) bool {
+		a, b := rs[i], rs[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Start.Line != b.Start.Line {
+			return a.Start.Line < b.Start.Line
+		}
+		return a.Start.Column < b.Start.Column
+	})
+}
+
+// sortedKeys 返回排好序的键："文件名:行号"形式的键先按文件名、再按行号的数值排列，
+// 其他形式的键（如符号）排在它们之后，按字符串排列
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		fileA, lineA, okA := splitKey(keys[i])
+		fileB, lineB, okB := splitKey(keys[j])
+		switch {
+		case okA != okB:
+			return okA
+		case !okA:
+			return keys[i] < keys[j]
+		case fileA != fileB:
+			return fileA < fileB
+		}
+		return lineA < lineB
+	})
+	return keys
+}
+
+func marshalOrdered[V any](m map[string]V) ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range sortedKeys(m) {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(m[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,58 @@
+package getcomments
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommentsMapKeysOrder(t *testing.T) {
+	m := CommentsMap{
+		"b.go:2":   {"// b"},
+		"a.go:10":  {"// a10"},
+		"a.go:9":   {"// a9"},
+		"a.go.x:1": {"// x"},
+		"demo.Run": {"// 符号"},
+	}
+	want := []string{"a.go:9", "a.go:10", "a.go.x:1", "b.go:2", "demo.Run"}
+	if got := m.Keys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("键的顺序为 %v，期望 %v", got, want)
+	}
+
+	output, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+	if strings.Index(string(output), `"a.go:9"`) > strings.Index(string(output), `"a.go:10"`) {
+		t.Errorf("JSON中的键没有按行号排列: %s", output)
+	}
+	var decoded CommentsMap
+	if err := json.Unmarshal(output, &decoded); err != nil || !reflect.DeepEqual(decoded, m) {
+		t.Errorf("反序列化结果不一致: %v, %v", decoded, err)
+	}
+}
+
+func TestCommentRecordsSort(t *testing.T) {
+	code := `package demo
+
+func f() {
+	x := 1 // 行尾
+}
+
+// g 文档
+func g() {}
+`
+	records, err := ExtractCommentRecordsFrom(FromSource("demo.go", []byte(code)))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	records.Sort()
+	var texts []string
+	for _, r := range records {
+		texts = append(texts, r.Text)
+	}
+	if want := []string{"行尾", "g 文档"}; !reflect.DeepEqual(texts, want) {
+		t.Errorf("排序后的注释为 %v，期望 %v", texts, want)
+	}
+}
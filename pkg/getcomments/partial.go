@@ -0,0 +1,66 @@
+package getcomments
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"strings"
+)
+
+// SyntaxError 带位置的语法错误
+type SyntaxError struct {
+	File    string   `json:"file"`
+	Pos     Position `json:"pos"`
+	Message string   `json:"message"`
+}
+
+// Error 实现error接口
+func (e SyntaxError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Pos.Line, e.Pos.Column, e.Message)
+}
+
+// SyntaxErrors 一个文件中的所有语法错误
+// 容错模式下，Extract 在返回部分结果的同时返回该类型的错误，调用方可以用errors.As取出
+type SyntaxErrors []SyntaxError
+
+// Error 实现error接口
+func (errs SyntaxErrors) Error() string {
+	switch len(errs) {
+	case 0:
+		return "没有语法错误"
+	case 1:
+		return "解析代码失败: " + errs[0].Error()
+	}
+	return fmt.Sprintf("解析代码失败: %s (以及其他 %d 个错误)", errs[0].Error(), len(errs)-1)
+}
+
+// WithPartial 启用容错模式：文件存在语法错误时使用解析器返回的部分AST与全部注释，
+// 能够关联的注释照常关联，Extract 同时返回部分结果与SyntaxErrors错误。
+// 只有提取注释时使用部分结果，其他需要完整AST的功能（如改写注释）仍然直接返回错误
+func WithPartial() Option {
+	return func(e *Extractor) {
+		e.partial = true
+	}
+}
+
+// AsSyntaxErrors 判断err是否是容错模式下的语法错误，是时返回其中的错误列表
+func AsSyntaxErrors(err error) (SyntaxErrors, bool) {
+	var errs SyntaxErrors
+	if errors.As(err, &errs) {
+		return errs, true
+	}
+	return nil, false
+}
+
+// newSyntaxErrors 转换解析器返回的错误列表
+func newSyntaxErrors(filename string, list scanner.ErrorList) SyntaxErrors {
+	errs := make(SyntaxErrors, 0, len(list))
+	for _, e := range list {
+		errs = append(errs, SyntaxError{
+			File:    filename,
+			Pos:     Position{Line: e.Pos.Line, Column: e.Pos.Column},
+			Message: strings.TrimSpace(e.Msg),
+		})
+	}
+	return errs
+}
@@ -0,0 +1,106 @@
+package getcomments
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractPartial(t *testing.T) {
+	src := `package main
+
+// Config 配置
+type Config struct {
+	Name string // 名称
+}
+
+// broken 写了一半的函数
+func broken() {
+	x := // 未完成的赋值
+}
+
+// Run 运行
+func Run() {
+	// 打印
+	println("ok")
+}
+`
+	in := FromSource("broken.go", []byte(src))
+
+	// 默认模式直接返回错误
+	if _, err := NewExtractor().Extract(in); err == nil {
+		t.Fatal("默认模式下期望返回解析错误")
+	} else if _, ok := AsSyntaxErrors(err); ok {
+		t.Errorf("默认模式下不应返回SyntaxErrors: %v", err)
+	}
+
+	records, err := NewExtractor(WithPartial()).Extract(in)
+	errs, ok := AsSyntaxErrors(err)
+	if !ok || len(errs) == 0 {
+		t.Fatalf("容错模式下期望返回SyntaxErrors, 得到 %v", err)
+	}
+	if errs[0].File != "broken.go" || errs[0].Pos.Line != 11 {
+		t.Errorf("第一个语法错误 = %+v, 期望位于 broken.go 第11行", errs[0])
+	}
+
+	texts := make(map[int]string)
+	for _, r := range records {
+		texts[r.Line] = r.Text
+	}
+	// 错误之后的函数不在部分AST中，注释按行关联到下方的代码行
+	for line, want := range map[int]string{
+		4:  "Config 配置",
+		5:  "名称",
+		9:  "broken 写了一半的函数",
+		14: "Run 运行",
+		16: "打印",
+	} {
+		if texts[line] != want {
+			t.Errorf("第%d行的注释 = %q, 期望 %q", line, texts[line], want)
+		}
+	}
+}
+
+func TestExtractPartialShapes(t *testing.T) {
+	// 各种写了一半的代码都不能导致崩溃
+	sources := []string{
+		"package main\n\n// 注释\nfunc f(",
+		"package main\n\n// 注释\ntype T struct {\n\tA int // 字段\n",
+		"package main\n\nimport (\n\t\"fmt\" // 格式化\n\nfunc main() { fmt.Println( }\n",
+		"package main\n\nvar x = []int{1, 2 // 列表\n\n// 下一个\nfunc g() {}\n",
+		"// 包注释\npackage\n\n// 注释\n",
+		"package main\n\nfunc h() {\n\tif x { // 条件\n\t\tswitch {\n\tcase 1 // 分支\n}\n",
+		"package main\n\n/* 未结束的块注释\nfunc f() {}\n",
+	}
+	for _, src := range sources {
+		records, err := NewExtractor(WithPartial()).Extract(FromSource("x.go", []byte(src)))
+		if err == nil {
+			t.Errorf("期望语法错误: %q", src)
+		}
+		if _, ok := AsSyntaxErrors(err); !ok {
+			t.Errorf("期望SyntaxErrors, 得到 %v: %q", err, src)
+		}
+		_ = records.GroupedOutput()
+	}
+}
+
+func TestExtractDirPartial(t *testing.T) {
+	root := createDirTestProject(t)
+
+	result, err := NewExtractor(WithPartial()).ExtractDir(filepath.Join(root, "..."))
+	if err != nil {
+		t.Fatalf("ExtractDir出错: %v", err)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("容错模式下语法错误不应记为文件错误: %v", result.Errors)
+	}
+	if len(result.SyntaxErrors) == 0 || result.SyntaxErrors[0].File != "cmd/b/broken.go" {
+		t.Errorf("SyntaxErrors = %v, 期望包含 cmd/b/broken.go 的错误", result.SyntaxErrors)
+	}
+	found := false
+	for _, file := range result.Files {
+		found = found || file == "cmd/b/broken.go"
+	}
+	if !found {
+		t.Errorf("Files = %v, 期望包含提取了部分结果的 cmd/b/broken.go", result.Files)
+	}
+}
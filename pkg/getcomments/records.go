@@ -0,0 +1,336 @@
+package getcomments
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+)
+
+// CommentKind 注释的类型
+type CommentKind string
+
+const (
+	// KindDoc 声明、规格或字段的文档注释
+	KindDoc CommentKind = "doc"
+	// KindLeading 位于代码上方的独立注释
+	KindLeading CommentKind = "leading"
+	// KindTrailing 位于代码行尾的注释
+	KindTrailing CommentKind = "trailing"
+)
+
+// Position 注释在文件中的行列位置（均从1开始，列号按字节计算，与go/token一致）
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// OwnerKind 字段节点在所属类型中的角色
+type OwnerKind string
+
+const (
+	// OwnerField 结构体的具名字段
+	OwnerField OwnerKind = "field"
+	// OwnerEmbedded 结构体的嵌入字段或接口中嵌入的接口
+	OwnerEmbedded OwnerKind = "embedded"
+	// OwnerMethod 接口的方法
+	OwnerMethod OwnerKind = "method"
+	// OwnerTypeSet 接口类型集合中的元素，如 ~int | ~string
+	OwnerTypeSet OwnerKind = "type-set"
+	// OwnerTypeParam 类型或函数的类型参数
+	OwnerTypeParam OwnerKind = "type-param"
+)
+
+// NodeInfo 描述注释所关联的AST节点
+type NodeInfo struct {
+	Kind string `json:"kind"`           // 节点类型，如 FuncDecl、Field
+	Name string `json:"name,omitempty"` // 节点名称，如函数名、字段名
+}
+
+// String 返回 "FuncDecl ExampleGetComments" 形式的节点描述
+func (n NodeInfo) String() string {
+	if n.Name == "" {
+		return n.Kind
+	}
+	return n.Kind + " " + n.Name
+}
+
+// CommentRecord 是一条结构化的注释记录
+// 除了CommentsMap中的"文件名:行号"信息外，还保留了注释的行列范围、
+// 原始文本与清洗后的文本、注释类型以及所关联的AST节点
+type CommentRecord struct {
+	File  string      `json:"file"`  // 文件名，与CommentsMap键中的文件名一致
+	Line  int         `json:"line"`  // 关联代码所在的行号，与CommentsMap键中的行号一致
+	Start Position    `json:"start"` // 注释起始位置
+	End   Position    `json:"end"`   // 注释结束位置
+	Raw   string      `json:"raw"`   // 原始注释文本，包含 // 或 /* */
+	Text  string      `json:"text"`  // 去除注释符号后的文本
+	Kind  CommentKind `json:"kind"`  // 注释类型
+	Block bool        `json:"block"` // 是否为 /* */ 块注释
+	Node  NodeInfo    `json:"node"`  // 注释关联的AST节点
+
+	// Symbol 注释所属的限定符号，如 main.MainEntry.Dir、main.(*RegistryController).run#stmt3，不随行号变化
+	Symbol string `json:"symbol,omitempty"`
+
+	// Owner 与 Path 只用于关联到字段、接口方法、类型集合元素与类型参数的注释
+	Owner OwnerKind `json:"owner,omitempty"` // 所关联的节点在所属类型中的角色
+	Path  string    `json:"path,omitempty"`  // 字段在所属类型中的路径，匿名结构体的字段以点连接外层字段名，如 Config.Server.Addr
+
+	Generated bool `json:"generated,omitempty"` // 注释所在的文件是否是生成文件
+}
+
+// Key 返回记录在CommentsMap中对应的"文件名:行号"键
+func (r CommentRecord) Key() string {
+	return fmt.Sprintf("%s:%d", r.File, r.Line)
+}
+
+// CommentRecords 按提取顺序排列的注释记录
+type CommentRecords []CommentRecord
+
+// CommentsMap 将注释记录投影为"文件名:行号"到原始注释文本的映射
+func (rs CommentRecords) CommentsMap() CommentsMap {
+	commentsMap := make(CommentsMap)
+	for _, r := range rs {
+		key := r.Key()
+		commentsMap[key] = append(commentsMap[key], r.Raw)
+	}
+	return commentsMap
+}
+
+// SchemaVersion 分组输出格式的版本号，扁平的CommentsMap视为版本1
+const SchemaVersion = 2
+
+// GroupedComments 一个代码行按类型分组的注释，每组保持注释在文件中的顺序
+type GroupedComments struct {
+	Doc      []string `json:"doc,omitempty"`
+	Leading  []string `json:"leading,omitempty"`
+	Trailing []string `json:"trailing,omitempty"`
+}
+
+// GroupedCommentsMap 保存"文件名:行号"到分组注释的映射
+type GroupedCommentsMap map[string]*GroupedComments
+
+// GroupedOutput 带版本号的分组输出格式
+type GroupedOutput struct {
+	Version   int                `json:"version"`
+	Comments  GroupedCommentsMap `json:"comments"`
+	Generated []string           `json:"generated,omitempty"` // 结果中包含的生成文件
+	Errors    []SyntaxError      `json:"errors,omitempty"`    // 容错模式下的语法错误
+}
+
+// GroupedMap 将注释记录按"文件名:行号"与注释类型分组
+func (rs CommentRecords) GroupedMap() GroupedCommentsMap {
+	grouped := make(GroupedCommentsMap)
+	for _, r := range rs {
+		g := grouped[r.Key()]
+		if g == nil {
+			g = &GroupedComments{}
+			grouped[r.Key()] = g
+		}
+		g.add(r)
+	}
+	return grouped
+}
+
+// add 按注释类型添加一条记录
+func (g *GroupedComments) add(r CommentRecord) {
+	switch r.Kind {
+	case KindDoc:
+		g.Doc = append(g.Doc, r.Raw)
+	case KindTrailing:
+		g.Trailing = append(g.Trailing, r.Raw)
+	default:
+		g.Leading = append(g.Leading, r.Raw)
+	}
+}
+
+// GroupedOutput 返回当前版本的分组输出
+func (rs CommentRecords) GroupedOutput() *GroupedOutput {
+	output := &GroupedOutput{Version: SchemaVersion, Comments: rs.GroupedMap()}
+	seen := make(map[string]bool)
+	for _, r := range rs {
+		if r.Generated && !seen[r.File] {
+			seen[r.File] = true
+			output.Generated = append(output.Generated, r.File)
+		}
+	}
+	return output
+}
+
+// recordBuilder 按关联代码行收集注释记录
+// 每个代码行只接收第一批关联到它的注释，与CommentsMap的去重规则保持一致
+type recordBuilder struct {
+	fset     *token.FileSet
+	filename string
+	lines    []string
+	docs     map[*ast.Comment]bool
+	symbols  *symbolIndex
+	keys     map[int]bool
+	records  CommentRecords
+	owners   []ast.Node // 每条记录所属符号的节点，与records一一对应，属于包时为nil
+}
+
+func newRecordBuilder(fset *token.FileSet, filename string, f *ast.File, lines []string) *recordBuilder {
+	return &recordBuilder{
+		fset:     fset,
+		filename: filename,
+		lines:    lines,
+		docs:     collectDocComments(f),
+		symbols:  newSymbolIndex(f),
+		keys:     make(map[int]bool),
+	}
+}
+
+// add 将关联到line行node节点的注释转换为记录
+func (b *recordBuilder) add(line int, node ast.Node, comments []comment) {
+	b.addOwned(line, node, "", "", comments)
+}
+
+// addField 与add相同，记录带有字段的角色与路径
+func (b *recordBuilder) addField(line int, field *ast.Field, owner OwnerKind, path string, comments []comment) {
+	b.addOwned(line, field, owner, path, comments)
+}
+
+func (b *recordBuilder) addOwned(line int, node ast.Node, owner OwnerKind, path string, comments []comment) {
+	if len(comments) == 0 || b.keys[line] {
+		return
+	}
+	b.keys[line] = true
+	info := describeNode(node)
+	symbol, ownerNode := b.symbols.lookup(node.Pos())
+	for _, c := range comments {
+		start := b.fset.Position(c.node.Pos())
+		end := b.fset.Position(c.node.End())
+		b.records = append(b.records, CommentRecord{
+			File:  b.filename,
+			Line:  line,
+			Start: Position{Line: start.Line, Column: start.Column},
+			End:   Position{Line: end.Line, Column: end.Column},
+			Raw:   c.content,
+			Text:  cleanCommentText(c.content),
+			Kind:  b.kindOf(c.node, start),
+			Block: strings.HasPrefix(c.content, "/*"),
+			Node:  info,
+
+			Symbol: symbol,
+			Owner:  owner,
+			Path:   path,
+		})
+		b.owners = append(b.owners, ownerNode)
+	}
+}
+
+// kindOf 判断注释类型：属于文档注释组的为doc，同一行前面有代码的为trailing，其余为leading
+// 前面只有其他块注释时（如 "/* a */ /* b */"）不算有代码
+func (b *recordBuilder) kindOf(c *ast.Comment, start token.Position) CommentKind {
+	if b.docs[c] {
+		return KindDoc
+	}
+	if start.Line > 0 && start.Line <= len(b.lines) {
+		line := b.lines[start.Line-1]
+		if start.Column-1 <= len(line) && !onlyBlockComments(line[:start.Column-1]) {
+			return KindTrailing
+		}
+	}
+	return KindLeading
+}
+
+// onlyBlockComments 判断文本是否只包含空白与完整的块注释
+func onlyBlockComments(s string) bool {
+	for {
+		s = strings.TrimSpace(s)
+		if !strings.HasPrefix(s, "/*") {
+			return s == ""
+		}
+		end := strings.Index(s[2:], "*/")
+		if end < 0 {
+			return false
+		}
+		s = s[2+end+2:]
+	}
+}
+
+// collectDocComments 收集文件中所有文档注释组包含的注释
+func collectDocComments(f *ast.File) map[*ast.Comment]bool {
+	docs := make(map[*ast.Comment]bool)
+	mark := func(cg *ast.CommentGroup) {
+		if cg == nil {
+			return
+		}
+		for _, c := range cg.List {
+			docs[c] = true
+		}
+	}
+	mark(f.Doc)
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncDecl:
+			mark(node.Doc)
+		case *ast.GenDecl:
+			mark(node.Doc)
+		case *ast.TypeSpec:
+			mark(node.Doc)
+		case *ast.ValueSpec:
+			mark(node.Doc)
+		case *ast.ImportSpec:
+			mark(node.Doc)
+		case *ast.Field:
+			mark(node.Doc)
+		}
+		return true
+	})
+	return docs
+}
+
+// describeNode 返回节点的类型与名称
+func describeNode(n ast.Node) NodeInfo {
+	if n == nil {
+		return NodeInfo{}
+	}
+	info := NodeInfo{Kind: strings.TrimPrefix(fmt.Sprintf("%T", n), "*ast.")}
+	switch node := n.(type) {
+	case *ast.File:
+		info.Name = node.Name.Name
+	case *ast.FuncDecl:
+		info.Name = node.Name.Name
+	case *ast.GenDecl:
+		info.Name = node.Tok.String()
+	case *ast.TypeSpec:
+		info.Name = node.Name.Name
+	case *ast.ValueSpec:
+		info.Name = joinIdents(node.Names)
+	case *ast.ImportSpec:
+		info.Name = node.Path.Value
+	case *ast.Field:
+		if len(node.Names) > 0 {
+			info.Name = joinIdents(node.Names)
+		} else {
+			info.Name = types.ExprString(node.Type)
+		}
+	case *ast.Ident:
+		info.Name = node.Name
+	}
+	return info
+}
+
+func joinIdents(idents []*ast.Ident) string {
+	names := make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Name
+	}
+	return strings.Join(names, ",")
+}
+
+// cleanCommentText 去除注释符号及首尾空白
+func cleanCommentText(raw string) string {
+	if strings.HasPrefix(raw, "//") {
+		return strings.TrimSpace(raw[2:])
+	}
+	text := strings.TrimSuffix(strings.TrimPrefix(raw, "/*"), "*/")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,186 @@
+package getcomments
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExtractCommentRecords(t *testing.T) {
+	exampleFile := filepath.Join("testdata", "examples.go")
+
+	records, err := ExtractCommentRecords(exampleFile)
+	if err != nil {
+		t.Fatalf("提取注释记录失败: %v", err)
+	}
+
+	// CommentsMap 应当是注释记录的投影
+	commentsMap, err := ExtractComments(exampleFile)
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	if !reflect.DeepEqual(records.CommentsMap(), commentsMap) {
+		t.Errorf("注释记录的投影与ExtractComments结果不一致")
+	}
+
+	byText := make(map[string]CommentRecord)
+	for _, r := range records {
+		byText[r.Text] = r
+	}
+
+	testCases := []struct {
+		text  string
+		line  int
+		start Position
+		kind  CommentKind
+		node  string
+	}{
+		{"ExampleGetComments 示例", 11, Position{Line: 6, Column: 1}, KindDoc, "FuncDecl ExampleGetComments"},
+		{"函数名: 函数注释 ExampleGetComments", 11, Position{Line: 11, Column: 29}, KindTrailing, "FuncDecl ExampleGetComments"},
+		{"定义两个变量", 13, Position{Line: 12, Column: 2}, KindLeading, "AssignStmt"},
+		{"if 分支", 17, Position{Line: 17, Column: 13}, KindTrailing, "IfStmt"},
+		{"导入标准库", 4, Position{Line: 3, Column: 1}, KindDoc, "GenDecl import"},
+	}
+
+	for _, tc := range testCases {
+		r, ok := byText[tc.text]
+		if !ok {
+			t.Errorf("未找到注释 %q", tc.text)
+			continue
+		}
+		if r.File != "examples.go" || r.Line != tc.line {
+			t.Errorf("注释 %q 的键为 %s, 期望 examples.go:%d", tc.text, r.Key(), tc.line)
+		}
+		if r.Start != tc.start {
+			t.Errorf("注释 %q 的起始位置为 %+v, 期望 %+v", tc.text, r.Start, tc.start)
+		}
+		if r.Kind != tc.kind {
+			t.Errorf("注释 %q 的类型为 %s, 期望 %s", tc.text, r.Kind, tc.kind)
+		}
+		if r.Node.String() != tc.node {
+			t.Errorf("注释 %q 关联的节点为 %q, 期望 %q", tc.text, r.Node, tc.node)
+		}
+		if r.Raw != "// "+tc.text {
+			t.Errorf("注释 %q 的原始文本为 %q", tc.text, r.Raw)
+		}
+	}
+}
+
+func TestExtractCommentRecordsFields(t *testing.T) {
+	code := `package p
+
+type S struct {
+	Dir  string // 目录
+	File string /* 文件名 */
+}
+`
+	records, err := ExtractCommentRecords(code)
+	if err != nil {
+		t.Fatalf("提取注释记录失败: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("得到 %d 条记录, 期望 2", len(records))
+	}
+	if got := records[0].Node.String(); got != "Field Dir" {
+		t.Errorf("第一条记录关联的节点为 %q, 期望 %q", got, "Field Dir")
+	}
+	if !records[1].Block || records[1].Text != "文件名" {
+		t.Errorf("块注释记录不正确: %+v", records[1])
+	}
+	if records[1].End != (Position{Line: 5, Column: 29}) {
+		t.Errorf("块注释结束位置为 %+v", records[1].End)
+	}
+}
+
+func TestCommentRecordsGroupedMap(t *testing.T) {
+	records, err := ExtractCommentRecordsFrom(FromFile(filepath.Join("testdata", "examples.go")))
+	if err != nil {
+		t.Fatalf("提取注释记录失败: %v", err)
+	}
+
+	output := records.GroupedOutput()
+	if output.Version != SchemaVersion {
+		t.Errorf("版本为 %d, 期望 %d", output.Version, SchemaVersion)
+	}
+	if len(output.Comments) != len(records.CommentsMap()) {
+		t.Errorf("分组映射有 %d 个键, 扁平映射有 %d 个", len(output.Comments), len(records.CommentsMap()))
+	}
+
+	fn := output.Comments["examples.go:11"]
+	if fn == nil {
+		t.Fatalf("未找到键 examples.go:11")
+	}
+	if len(fn.Doc) != 5 || fn.Doc[0] != "// ExampleGetComments 示例" || len(fn.Leading) != 0 {
+		t.Errorf("函数的文档注释为 %q, 上方注释为 %q", fn.Doc, fn.Leading)
+	}
+	if !reflect.DeepEqual(fn.Trailing, []string{"// 函数名: 函数注释 ExampleGetComments"}) {
+		t.Errorf("函数的行尾注释为 %q", fn.Trailing)
+	}
+
+	stmt := output.Comments["examples.go:22"]
+	want := &GroupedComments{
+		Leading:  []string{"// 打印输出： x is less than y"},
+		Trailing: []string{"// 行尾注释打印输出： x is less than y"},
+	}
+	if !reflect.DeepEqual(stmt, want) {
+		t.Errorf("examples.go:22 的分组注释为 %+v, 期望 %+v", stmt, want)
+	}
+}
+
+// TestExtractCommentRecordsGenerics 类型参数、类型集合、嵌入字段与匿名结构体字段的回归测试
+func TestExtractCommentRecordsGenerics(t *testing.T) {
+	records, err := ExtractCommentRecordsFrom(FromFile(filepath.Join("testdata", "generics.go")))
+	if err != nil {
+		t.Fatalf("提取注释记录失败: %v", err)
+	}
+	if len(records) != 23 {
+		t.Errorf("得到 %d 条记录, 期望 23，每条注释都应被关联", len(records))
+	}
+
+	byText := make(map[string]CommentRecord)
+	for _, r := range records {
+		byText[r.Text] = r
+	}
+	testCases := []struct {
+		text   string
+		line   int
+		symbol string
+		owner  OwnerKind
+		path   string
+	}{
+		{"整数", 7, "testdata.Number", OwnerTypeSet, ""},
+		{"浮点数", 9, "testdata.Number", OwnerTypeSet, ""},
+		{"字符串表示", 10, "testdata.Number.String", OwnerMethod, "String"},
+		{"键", 15, "testdata.Cache[K]", OwnerTypeParam, "K"},
+		// 类型参数上方的多行注释全部关联到类型参数
+		{"值", 18, "testdata.Cache[V]", OwnerTypeParam, "V"},
+		{"可以是任意类型", 18, "testdata.Cache[V]", OwnerTypeParam, "V"},
+		{"嵌入的泛型存储", 20, "testdata.Cache.Store", OwnerEmbedded, "Store"},
+		{"嵌入的互斥锁", 21, "testdata.Cache.Mutex", OwnerEmbedded, "Mutex"},
+		{"Config 配置", 23, "testdata.Cache.Config", OwnerField, "Config"},
+		{"名称", 24, "testdata.Cache.Config.Name", OwnerField, "Config.Name"},
+		// 匿名结构体中字段的多行文档注释
+		{"地址", 28, "testdata.Cache.Config.Server.Addr", OwnerField, "Config.Server.Addr"},
+		{"形如 host:port", 28, "testdata.Cache.Config.Server.Addr", OwnerField, "Config.Server.Addr"},
+		{"钩子名称", 32, "testdata.Cache.hooks.Name", OwnerField, "hooks.Name"},
+		{"元素类型", 39, "testdata.Sum[T]", OwnerTypeParam, "T"},
+		{"累加", 43, "testdata.Sum#stmt2.1", "", ""},
+		{"泛型接收者", 49, "testdata.(*Cache).Get", "", ""},
+		{"是否保留", 56, "testdata.Filter[T].Keep", OwnerMethod, "T.Keep"},
+		{"输出详细信息", 62, "testdata.options.Verbose", OwnerField, "Verbose"},
+	}
+	for _, tc := range testCases {
+		r, ok := byText[tc.text]
+		if !ok {
+			t.Errorf("未找到注释 %q", tc.text)
+			continue
+		}
+		if r.Line != tc.line || r.Symbol != tc.symbol || r.Owner != tc.owner || r.Path != tc.path {
+			t.Errorf("注释 %q 为 行%d %s %q %q, 期望 行%d %s %q %q",
+				tc.text, r.Line, r.Symbol, r.Owner, r.Path, tc.line, tc.symbol, tc.owner, tc.path)
+		}
+	}
+	if kind := byText["形如 host:port"].Kind; kind != KindDoc {
+		t.Errorf("匿名结构体字段的文档注释类型为 %s, 期望 doc", kind)
+	}
+}
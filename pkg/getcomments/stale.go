@@ -0,0 +1,462 @@
+package getcomments
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// StaleReport 目录模式下过时引用的检查结果
+type StaleReport struct {
+	Diagnostics []Diagnostic `json:"diagnostics"`
+	Errors      []FileError  `json:"errors,omitempty"`
+}
+
+var (
+	// 反引号中的标识符引用，如 `name`、`*Extractor`、`Filter.DocOnly`、`Extract()`
+	backtickRefPattern = regexp.MustCompile("`(\\*?[A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*)*)(?:\\(\\))?`")
+	// 反引号包围的任意内容
+	backtickPattern = regexp.MustCompile("`[^`]*`")
+	// 普通文本中的单词，可以是点号连接的选择器
+	wordPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)*`)
+	// 文档中的链接
+	urlPattern = regexp.MustCompile(`[a-z]+://\S+`)
+)
+
+// FindStaleRefs 检查输入中文档注释引用的标识符是否仍然存在
+func FindStaleRefs(in Input) ([]Diagnostic, error) {
+	return defaultExtractor.FindStaleRefs(in)
+}
+
+// FindStaleRefsDir 按目录模式检查过时的引用，pattern 的规则与ExtractDir相同
+func FindStaleRefsDir(pattern string) (*StaleReport, error) {
+	return defaultExtractor.FindStaleRefsDir(pattern)
+}
+
+// FindStaleRefs 使用提取器的配置检查文档注释中过时的标识符引用
+// 检查的引用包括反引号中的名称，以及普通文本中小驼峰或带下划线的标识符；
+// 引用在所注释的声明中（参数、返回值、字段、局部变量等）、包级声明、类型成员与预声明标识符中都找不到时报告。
+// 单个文件只能看到本文件的包级声明，需要跨文件检查时使用FindStaleRefsDir
+func (e *Extractor) FindStaleRefs(in Input) ([]Diagnostic, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	scope := newPackageScope()
+	scope.addFile(parsed.File)
+	c := &staleChecker{fset: parsed.Fset, filename: in.Filename(), scope: scope}
+	c.checkFile(parsed.File)
+	return c.diagnostics, nil
+}
+
+// FindStaleRefsDir 使用提取器的配置按目录模式检查过时的引用，同一目录中的文件共用包级声明
+func (e *Extractor) FindStaleRefsDir(pattern string) (*StaleReport, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	report := &StaleReport{Errors: dir.Errors}
+
+	type file struct {
+		filename string
+		parsed   *ParsedFile
+	}
+	scopes := make(map[string]*packageScope)
+	var files []file
+	for _, in := range dir.Inputs {
+		parsed, err := e.parse(in)
+		if err != nil {
+			report.Errors = append(report.Errors, FileError{File: in.Filename(), Err: err.Error()})
+			continue
+		}
+		pkg := path.Dir(in.Filename())
+		if scopes[pkg] == nil {
+			scopes[pkg] = newPackageScope()
+		}
+		scopes[pkg].addFile(parsed.File)
+		files = append(files, file{in.Filename(), parsed})
+	}
+
+	for _, f := range files {
+		c := &staleChecker{fset: f.parsed.Fset, filename: f.filename, scope: scopes[path.Dir(f.filename)]}
+		c.checkFile(f.parsed.File)
+		report.Diagnostics = append(report.Diagnostics, c.diagnostics...)
+	}
+	return report, nil
+}
+
+// packageScope 一个包中声明的名称
+type packageScope struct {
+	names       map[string]bool            // 包名、导入的包名与包级声明
+	declared    map[string]bool            // 包级声明，不包括包名与导入的包名
+	members     map[string]map[string]bool // 类型名 -> 字段与方法
+	memberNames map[string]bool            // 所有类型的字段与方法
+}
+
+func newPackageScope() *packageScope {
+	return &packageScope{
+		names:       make(map[string]bool),
+		declared:    make(map[string]bool),
+		members:     make(map[string]map[string]bool),
+		memberNames: make(map[string]bool),
+	}
+}
+
+// declare 记录一个包级声明
+func (s *packageScope) declare(name string) {
+	s.names[name] = true
+	s.declared[name] = true
+}
+
+func (s *packageScope) addFile(f *ast.File) {
+	s.names[f.Name.Name] = true
+	for _, spec := range f.Imports {
+		if spec.Name != nil {
+			s.names[spec.Name.Name] = true
+		} else if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+			s.names[importName(importPath)] = true
+		}
+	}
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				s.addMember(receiverTypeName(d.Recv.List[0].Type), d.Name.Name)
+			} else {
+				s.declare(d.Name.Name)
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					s.declare(sp.Name.Name)
+					s.addTypeMembers(sp.Name.Name, sp.Type)
+				case *ast.ValueSpec:
+					for _, ident := range sp.Names {
+						s.declare(ident.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// addTypeMembers 记录结构体的字段与接口的方法，嵌入字段以类型名作为字段名
+func (s *packageScope) addTypeMembers(owner string, typ ast.Expr) {
+	var fields *ast.FieldList
+	switch t := typ.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	}
+	if fields == nil {
+		return
+	}
+	for _, field := range fields.List {
+		for _, ident := range field.Names {
+			s.addMember(owner, ident.Name)
+		}
+		if len(field.Names) == 0 {
+			if ident := embeddedFieldIdent(field.Type); ident != nil {
+				s.addMember(owner, ident.Name)
+			}
+		}
+	}
+}
+
+func (s *packageScope) addMember(owner, name string) {
+	if s.members[owner] == nil {
+		s.members[owner] = make(map[string]bool)
+	}
+	s.members[owner][name] = true
+	s.memberNames[name] = true
+}
+
+// importName 返回导入路径默认的包名，省略 /v2 形式的主版本后缀
+func importName(importPath string) string {
+	name := path.Base(importPath)
+	if len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = path.Base(path.Dir(importPath))
+	}
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+// staleChecker 检查一个文件的文档注释
+type staleChecker struct {
+	fset        *token.FileSet
+	filename    string
+	scope       *packageScope
+	diagnostics []Diagnostic
+}
+
+// staleDecl 一个声明中可以被文档引用的名称
+type staleDecl struct {
+	target     NodeInfo
+	idents     map[string]bool // 声明中出现的所有标识符
+	candidates []string        // 参数、返回值、字段等，用于给出改名建议
+}
+
+func (c *staleChecker) checkFile(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sd := newStaleDecl(d)
+			sd.candidates = append(sd.candidates, d.Name.Name)
+			for _, fields := range []*ast.FieldList{d.Recv, d.Type.TypeParams, d.Type.Params, d.Type.Results} {
+				if fields == nil {
+					continue
+				}
+				for _, field := range fields.List {
+					for _, ident := range field.Names {
+						sd.candidates = append(sd.candidates, ident.Name)
+					}
+				}
+			}
+			c.checkGroup(sd, d.Doc)
+		case *ast.GenDecl:
+			sd := newStaleDecl(d)
+			var groups []*ast.CommentGroup
+			ast.Inspect(d, func(n ast.Node) bool {
+				switch node := n.(type) {
+				case *ast.TypeSpec:
+					sd.candidates = append(sd.candidates, node.Name.Name)
+					groups = append(groups, node.Doc, node.Comment)
+				case *ast.ValueSpec:
+					for _, ident := range node.Names {
+						sd.candidates = append(sd.candidates, ident.Name)
+					}
+					groups = append(groups, node.Doc, node.Comment)
+				case *ast.Field:
+					for _, ident := range node.Names {
+						sd.candidates = append(sd.candidates, ident.Name)
+					}
+					groups = append(groups, node.Doc, node.Comment)
+				}
+				return true
+			})
+			c.checkGroup(sd, d.Doc)
+			for _, cg := range groups {
+				c.checkGroup(sd, cg)
+			}
+		}
+	}
+}
+
+// newStaleDecl 收集声明中出现的标识符
+func newStaleDecl(decl ast.Node) *staleDecl {
+	sd := &staleDecl{target: describeNode(decl), idents: make(map[string]bool)}
+	ast.Inspect(decl, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			sd.idents[ident.Name] = true
+		}
+		return true
+	})
+	return sd
+}
+
+// checkGroup 检查一个注释组，同一个引用只报告第一次出现
+func (c *staleChecker) checkGroup(sd *staleDecl, cg *ast.CommentGroup) {
+	if cg == nil {
+		return
+	}
+	reported := make(map[string]bool)
+	for _, comment := range cg.List {
+		if IsDirective(comment.Text) {
+			continue
+		}
+		pos := c.fset.Position(comment.Pos())
+		for i, line := range strings.Split(comment.Text, "\n") {
+			// 块注释的后续行从第1列开始
+			column := 1
+			if i == 0 {
+				column = pos.Column
+			}
+			for _, ref := range findRefs(line) {
+				stale, candidates := c.resolve(sd, ref.name)
+				if stale == "" || reported[stale] {
+					continue
+				}
+				reported[stale] = true
+				c.report(sd, comment, line, ref, stale, candidates, Position{Line: pos.Line + i, Column: column + ref.offset})
+			}
+		}
+	}
+}
+
+// docRef 文档中的一个标识符引用
+type docRef struct {
+	name   string // 去除 * 与 () 后的名称，可以是点号连接的选择器
+	offset int    // 名称在行中的字节偏移
+}
+
+// findRefs 查找一行注释中的标识符引用，跳过代码块、链接与文件名
+func findRefs(line string) []docRef {
+	text := strings.TrimPrefix(strings.TrimPrefix(line, "//"), "/*")
+	if strings.HasPrefix(text, "\t") || strings.HasPrefix(text, "  ") {
+		// 缩进的代码块是示例代码，其中的变量不需要存在
+		return nil
+	}
+	masked := []byte(line)
+	mask := func(start, end int) {
+		for i := start; i < end; i++ {
+			masked[i] = ' '
+		}
+	}
+	for _, m := range urlPattern.FindAllStringIndex(line, -1) {
+		mask(m[0], m[1])
+	}
+
+	var refs []docRef
+	for _, m := range backtickRefPattern.FindAllStringSubmatchIndex(string(masked), -1) {
+		start := m[2]
+		if masked[start] == '*' {
+			start++
+		}
+		refs = append(refs, docRef{name: line[start:m[3]], offset: start})
+	}
+	for _, m := range backtickPattern.FindAllStringIndex(string(masked), -1) {
+		mask(m[0], m[1])
+	}
+
+	for _, m := range wordPattern.FindAllStringIndex(string(masked), -1) {
+		word := line[m[0]:m[1]]
+		if m[0] > 0 && strings.ContainsRune("/-.$%{", rune(line[m[0]-1])) ||
+			m[1] < len(line) && strings.ContainsRune("/.", rune(line[m[1]])) ||
+			isFileName(word) || !looksLikeRef(word) {
+			continue
+		}
+		refs = append(refs, docRef{name: word, offset: m[0]})
+	}
+	return refs
+}
+
+// looksLikeRef 判断普通文本中的单词是否是标识符引用：
+// 小驼峰或带下划线的单词，普通的英文单词与大写开头的名称不视为引用
+func looksLikeRef(word string) bool {
+	for _, part := range strings.Split(word, ".") {
+		if strings.Contains(strings.Trim(part, "_"), "_") {
+			return true
+		}
+		first, size := utf8.DecodeRuneInString(part)
+		if unicode.IsLower(first) && strings.IndexFunc(part[size:], unicode.IsUpper) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isFileName 判断选择器形式的单词是否是文件名，如 main.go、go.mod
+func isFileName(word string) bool {
+	switch path.Ext(word) {
+	case ".go", ".mod", ".sum", ".json", ".md", ".txt", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// resolve 查找引用的名称，返回不存在的部分以及改名建议的候选名称，引用存在时返回空字符串
+func (c *staleChecker) resolve(sd *staleDecl, name string) (string, []string) {
+	parts := strings.Split(name, ".")
+	first := parts[0]
+	if len(parts) > 1 {
+		if members, ok := c.scope.members[first]; ok {
+			// 类型的字段或方法
+			if members[parts[1]] {
+				return "", nil
+			}
+			var candidates []string
+			for member := range members {
+				candidates = append(candidates, member)
+			}
+			return first + "." + parts[1], candidates
+		}
+		if !c.known(sd, first) && ast.IsExported(first) {
+			return first, sd.candidates
+		}
+		// 变量的字段或者其他包中的名称，无法确定类型，不再检查
+		return "", nil
+	}
+	// 示例函数的名称（如 ExampleT_M）常作为命名规则出现在文档中，不要求存在
+	if c.known(sd, first) || isExampleName(first) {
+		return "", nil
+	}
+	return first, sd.candidates
+}
+
+func (c *staleChecker) known(sd *staleDecl, name string) bool {
+	return sd.idents[name] || c.scope.names[name] || c.scope.memberNames[name] ||
+		token.IsKeyword(name) || types.Universe.Lookup(name) != nil
+}
+
+// report 记录一个过时的引用，找到相近的名称时给出修改后的注释行
+func (c *staleChecker) report(sd *staleDecl, comment *ast.Comment, line string, ref docRef, stale string, candidates []string, pos Position) {
+	message := fmt.Sprintf("%s 的文档引用了不存在的 %s", sd.target, stale)
+	suggestion := ""
+	if closest := closestName(lastPart(stale), candidates); closest != "" {
+		message += fmt.Sprintf("，是否应为 %s", closest)
+		if !strings.Contains(comment.Text, "\n") {
+			start := ref.offset + strings.LastIndex(stale, ".") + 1
+			end := ref.offset + len(stale)
+			suggestion = line[:start] + closest + line[end:]
+		}
+	}
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		File:       c.filename,
+		Pos:        pos,
+		Rule:       "stale-ref",
+		Message:    message,
+		Suggestion: suggestion,
+	})
+}
+
+func lastPart(name string) string {
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// closestName 返回与名称最相近的候选名称：编辑距离不超过名称长度的一半，
+// 或者候选名称包含该名称（忽略大小写，名称至少3个字符）。
+// 候选名称比名称短时只按编辑距离判断，避免长名称被截断为短的前缀
+func closestName(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		if candidate == name || candidate == "_" {
+			continue
+		}
+		a, b := strings.ToLower(name), strings.ToLower(candidate)
+		distance := editDistance(a, b)
+		similar := distance*2 <= len(name) || len(a) >= 3 && strings.Contains(b, a)
+		if similar && (bestDistance < 0 || distance < bestDistance || distance == bestDistance && candidate < best) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance 计算两个字符串的编辑距离
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
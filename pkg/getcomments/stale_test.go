@@ -0,0 +1,83 @@
+package getcomments
+
+import (
+	"reflect"
+	"testing"
+)
+
+const staleTestCode = `package p
+
+import "strings"
+
+// Options 选项
+type Options struct {
+	// MaxLen 最大长度，0 表示使用 defaultMaxLen
+	MaxLen int
+	Prefix string // 与 maxLength 一起使用
+}
+
+const defaultMaxLen = 80
+
+// Trim 按 ` + "`opts.MaxLen`" + ` 截断srcText，返回值 ` + "`n`" + ` 为截断后的长度
+// 使用 ` + "`strings.TrimSpace`" + ` 与 ` + "`Options.Limit`" + `，参见 https://example.com/fooBar
+//
+//	trimmed := Trim(text, opts)
+func Trim(text string, opts Options) (result string, n int) {
+	text = strings.TrimSpace(text)
+	return text, len(text)
+}
+
+// Run 运行 ` + "`Missing`" + ` 与 ` + "`Missing`" + `，读取 main.go 与 ` + "`copy`" + `
+func Run() {}
+
+// Check 示例函数的名称如 ` + "`ExampleT_M`" + `、ExampleRun_fast 是命名规则，不需要存在
+func Check() {}
+`
+
+func TestFindStaleRefs(t *testing.T) {
+	diagnostics, err := FindStaleRefs(FromSource("p.go", []byte(staleTestCode)))
+	if err != nil {
+		t.Fatalf("检查过时引用失败: %v", err)
+	}
+
+	type stale struct {
+		pos        Position
+		message    string
+		suggestion string
+	}
+	want := []stale{
+		{Position{9, 23}, "GenDecl type 的文档引用了不存在的 maxLength，是否应为 MaxLen", "// 与 MaxLen 一起使用"},
+		{Position{14, 33}, "FuncDecl Trim 的文档引用了不存在的 srcText，是否应为 text", "// Trim 按 `opts.MaxLen` 截断text，返回值 `n` 为截断后的长度"},
+		{Position{15, 36}, "FuncDecl Trim 的文档引用了不存在的 Options.Limit", ""},
+		{Position{23, 16}, "FuncDecl Run 的文档引用了不存在的 Missing", ""},
+	}
+	var got []stale
+	for _, d := range diagnostics {
+		if d.Rule != "stale-ref" {
+			t.Errorf("规则为 %q", d.Rule)
+		}
+		got = append(got, stale{d.Pos, d.Message, d.Suggestion})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("过时引用为\n%v\n期望\n%v", got, want)
+	}
+}
+
+func TestClosestName(t *testing.T) {
+	candidates := []string{"e", "path", "filename", "Example", "readFileContent"}
+	tests := map[string]string{
+		"srcPath":  "path",
+		"fileName": "filename",
+		"max_len":  "",
+		"in":       "",
+		// 长名称不会被截断为候选中的短前缀
+		"ExampleExtractor_Extract_dir": "",
+		// 候选名称包含较短的名称时仍然相近
+		"readFile": "readFileContent",
+	}
+	for name, want := range tests {
+		if got := closestName(name, candidates); got != want {
+			t.Errorf("closestName(%q) = %q, 期望 %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package getcomments
+
+import (
+	"errors"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// CommentMetrics 一段代码的注释统计
+// 同一行既有代码又有注释时同时计入CodeLines与CommentLines；编译器与检查工具指令不算注释
+type CommentMetrics struct {
+	CodeLines    int     `json:"codeLines"`    // 含有代码的行数
+	CommentLines int     `json:"commentLines"` // 含有注释的行数
+	Density      float64 `json:"density"`      // 注释密度：CommentLines / (CodeLines + CommentLines)
+	DocLines     int     `json:"docLines"`     // 文档注释的行数
+	InlineLines  int     `json:"inlineLines"`  // 函数体内部注释的行数
+	Comments     int     `json:"comments"`     // 注释条数，块注释计为一条
+	AvgLength    float64 `json:"avgLength"`    // 注释去除注释符号后的平均字符数
+	CJKChars     int     `json:"cjkChars"`     // 注释中的中日韩文字数
+	LatinChars   int     `json:"latinChars"`   // 注释中的拉丁字母数
+	CJKRatio     float64 `json:"cjkRatio"`     // CJKChars / (CJKChars + LatinChars)，没有文字时为0
+
+	length int // 注释的总字符数，用于计算AvgLength
+}
+
+func (m *CommentMetrics) merge(other CommentMetrics) {
+	m.CodeLines += other.CodeLines
+	m.CommentLines += other.CommentLines
+	m.DocLines += other.DocLines
+	m.InlineLines += other.InlineLines
+	m.Comments += other.Comments
+	m.CJKChars += other.CJKChars
+	m.LatinChars += other.LatinChars
+	m.length += other.length
+}
+
+func (m *CommentMetrics) finish() {
+	m.Density, m.AvgLength, m.CJKRatio = 0, 0, 0
+	if lines := m.CodeLines + m.CommentLines; lines > 0 {
+		m.Density = round2(float64(m.CommentLines) / float64(lines))
+	}
+	if m.Comments > 0 {
+		m.AvgLength = round2(float64(m.length) / float64(m.Comments))
+	}
+	if letters := m.CJKChars + m.LatinChars; letters > 0 {
+		m.CJKRatio = round2(float64(m.CJKChars) / float64(letters))
+	}
+}
+
+// FunctionStats 一个函数或方法的注释统计
+type FunctionStats struct {
+	File  string   `json:"file"`
+	Pos   Position `json:"pos"`
+	Name  string   `json:"name"`  // 方法带有所属类型，如 Extractor.Extract
+	Lines int      `json:"lines"` // 从func关键字到右花括号的行数
+	CommentMetrics
+	Score float64 `json:"score"` // 缺少注释的程度：CodeLines * (1 - Density)，越大越需要补充注释
+}
+
+// FileStats 一个文件的注释统计
+type FileStats struct {
+	File string `json:"file"`
+	CommentMetrics
+	Functions []FunctionStats `json:"functions,omitempty"`
+}
+
+// StatsReport 注释统计报告
+type StatsReport struct {
+	CommentMetrics
+	Files     []FileStats     `json:"files"`
+	Functions []FunctionStats `json:"functions"`         // 所有函数按Score从大到小排列，即长且注释少的函数在前
+	Skipped   []string        `json:"skipped,omitempty"` // 按generated.Skip跳过的生成文件
+	Errors    []FileError     `json:"errors,omitempty"`
+}
+
+// CommentStats 统计输入中每个文件与函数的注释情况
+func CommentStats(in Input) (*FileStats, error) {
+	return defaultExtractor.CommentStats(in)
+}
+
+// CommentStatsDir 按目录模式统计注释情况，pattern 的规则与ExtractDir相同，_test.go 文件不参与统计
+func CommentStatsDir(pattern string) (*StatsReport, error) {
+	return defaultExtractor.CommentStatsDir(pattern)
+}
+
+// CommentStats 使用提取器的配置统计输入的注释情况
+// 代码行由go/scanner识别的记号决定，注释行由注释覆盖的行决定；函数的统计范围包括它的文档注释
+// 按generated.Skip跳过的生成文件返回空的统计
+func (e *Extractor) CommentStats(in Input) (*FileStats, error) {
+	fs, _, err := e.commentStats(in)
+	return fs, err
+}
+
+// commentStats 统计输入的注释情况，skip 表示输入是被跳过的生成文件
+func (e *Extractor) commentStats(in Input) (*FileStats, bool, error) {
+	parsed, skip, err := e.parseChecked(in)
+	if err != nil {
+		return nil, false, err
+	}
+	if skip {
+		return &FileStats{File: in.Filename()}, true, nil
+	}
+	s := newStatsFile(parsed)
+	result := &FileStats{File: in.Filename(), CommentMetrics: s.metrics(1, s.lineCount)}
+	for _, decl := range parsed.File.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Body == nil {
+			continue
+		}
+		name := funcDecl.Name.Name
+		if funcDecl.Recv != nil && len(funcDecl.Recv.List) > 0 {
+			if recv := receiverTypeName(funcDecl.Recv.List[0].Type); recv != "" {
+				name = recv + "." + name
+			}
+		}
+		pos := parsed.Fset.Position(funcDecl.Pos())
+		end := parsed.Fset.Position(funcDecl.End()).Line
+		from := pos.Line
+		if funcDecl.Doc != nil {
+			from = parsed.Fset.Position(funcDecl.Doc.Pos()).Line
+		}
+		fn := FunctionStats{
+			File:           in.Filename(),
+			Pos:            Position{Line: pos.Line, Column: pos.Column},
+			Name:           name,
+			Lines:          end - pos.Line + 1,
+			CommentMetrics: s.metrics(from, end),
+		}
+		fn.Score = round2(float64(fn.CodeLines) * (1 - fn.Density))
+		result.Functions = append(result.Functions, fn)
+	}
+	return result, false, nil
+}
+
+// CommentStatsDir 使用提取器的配置按目录模式统计注释情况，跳过的生成文件记录在Skipped中，
+// 拒绝处理生成文件时返回错误
+func (e *Extractor) CommentStatsDir(pattern string) (*StatsReport, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	var files []FileStats
+	var errs []FileError
+	var skipped []string
+	for _, in := range dir.Inputs {
+		if strings.HasSuffix(in.Filename(), "_test.go") {
+			continue
+		}
+		fs, skip, err := e.commentStats(in)
+		switch {
+		case errors.Is(err, generated.ErrGenerated):
+			return nil, err
+		case err != nil:
+			errs = append(errs, FileError{File: in.Filename(), Err: err.Error()})
+			continue
+		case skip:
+			skipped = append(skipped, in.Filename())
+			continue
+		}
+		files = append(files, *fs)
+	}
+	report := NewStatsReport(files)
+	report.Skipped = skipped
+	report.Errors = append(dir.Errors, errs...)
+	return report, nil
+}
+
+// NewStatsReport 汇总文件的注释统计，并按Score排列所有函数
+func NewStatsReport(files []FileStats) *StatsReport {
+	report := &StatsReport{Files: files, Functions: []FunctionStats{}}
+	for _, fs := range files {
+		report.merge(fs.CommentMetrics)
+		report.Functions = append(report.Functions, fs.Functions...)
+	}
+	report.finish()
+	sort.SliceStable(report.Functions, func(i, j int) bool {
+		a, b := report.Functions[i], report.Functions[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Pos.Line < b.Pos.Line
+	})
+	return report
+}
+
+// statsComment 一条注释的统计信息
+type statsComment struct {
+	line       int // 起始行
+	length     int
+	cjk, latin int
+}
+
+// statsFile 一个文件逐行的统计信息，切片按行号索引
+type statsFile struct {
+	lineCount int
+	code      []bool
+	comment   []bool
+	doc       []bool
+	inline    []bool
+	comments  []statsComment
+}
+
+func newStatsFile(parsed *ParsedFile) *statsFile {
+	file := parsed.Fset.File(parsed.File.Pos())
+	s := &statsFile{lineCount: file.LineCount()}
+	s.code = make([]bool, s.lineCount+1)
+	s.comment = make([]bool, s.lineCount+1)
+	s.doc = make([]bool, s.lineCount+1)
+	s.inline = make([]bool, s.lineCount+1)
+
+	// 代码行：忽略自动插入的分号，注释不会被扫描
+	var sc scanner.Scanner
+	scanFile := token.NewFileSet().AddFile("", -1, len(parsed.Content))
+	sc.Init(scanFile, parsed.Content, nil, 0)
+	for {
+		pos, tok, lit := sc.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok == token.SEMICOLON && lit == "\n" {
+			continue
+		}
+		// 跨行的原始字符串覆盖的每一行都是代码
+		end := pos
+		if tok == token.STRING {
+			end += token.Pos(len(lit) - 1)
+		}
+		for line := scanFile.Line(pos); line <= min(scanFile.Line(end), s.lineCount); line++ {
+			s.code[line] = true
+		}
+	}
+
+	// 函数体内部的范围
+	var bodies [][2]token.Pos
+	ast.Inspect(parsed.File, func(n ast.Node) bool {
+		if funcDecl, ok := n.(*ast.FuncDecl); ok && funcDecl.Body != nil {
+			bodies = append(bodies, [2]token.Pos{funcDecl.Body.Lbrace, funcDecl.Body.Rbrace})
+			return false
+		}
+		return true
+	})
+	inBody := func(pos token.Pos) bool {
+		for _, body := range bodies {
+			if pos > body[0] && pos < body[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	docs := collectDocComments(parsed.File)
+	for _, cg := range parsed.File.Comments {
+		for _, c := range cg.List {
+			if IsDirective(c.Text) {
+				continue
+			}
+			start, end := file.Line(c.Pos()), file.Line(c.End())
+			for line := start; line <= end; line++ {
+				s.comment[line] = true
+				s.doc[line] = s.doc[line] || docs[c]
+				s.inline[line] = s.inline[line] || inBody(c.Pos())
+			}
+			text := cleanCommentText(c.Text)
+			info := statsComment{line: start, length: utf8.RuneCountInString(text)}
+			for _, r := range text {
+				switch {
+				case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+					info.cjk++
+				case unicode.Is(unicode.Latin, r):
+					info.latin++
+				}
+			}
+			s.comments = append(s.comments, info)
+		}
+	}
+	return s
+}
+
+// metrics 统计第from到第to行（包括两端）
+func (s *statsFile) metrics(from, to int) CommentMetrics {
+	var m CommentMetrics
+	for line := max(from, 1); line <= min(to, s.lineCount); line++ {
+		if s.code[line] {
+			m.CodeLines++
+		}
+		if s.comment[line] {
+			m.CommentLines++
+		}
+		if s.doc[line] {
+			m.DocLines++
+		}
+		if s.inline[line] {
+			m.InlineLines++
+		}
+	}
+	for _, c := range s.comments {
+		if c.line >= from && c.line <= to {
+			m.Comments++
+			m.length += c.length
+			m.CJKChars += c.cjk
+			m.LatinChars += c.latin
+		}
+	}
+	m.finish()
+	return m
+}
+
+// round2 保留两位小数
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,121 @@
+package getcomments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+const statsTestCode = `package p
+
+// Short 有文档的短函数
+func Short() int {
+	return 1 // 返回1
+}
+
+func Long(n int) int {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += i
+	}
+	if total > 100 {
+		total = 100
+	}
+	return total
+}
+
+// Server serves requests.
+type Server struct{}
+
+// Start 启动 server
+func (s *Server) Start() {
+	/* 多行
+	   块注释 */
+	println(` + "`a\nb`" + `)
+}
+`
+
+func TestCommentStats(t *testing.T) {
+	fs, err := CommentStats(FromSource("p.go", []byte(statsTestCode)))
+	if err != nil {
+		t.Fatalf("统计注释失败: %v", err)
+	}
+	if len(fs.Functions) != 3 {
+		t.Fatalf("得到 %d 个函数, 期望 3", len(fs.Functions))
+	}
+
+	short, long, start := fs.Functions[0], fs.Functions[1], fs.Functions[2]
+	if short.Name != "Short" || short.CodeLines != 3 || short.CommentLines != 2 || short.DocLines != 1 || short.InlineLines != 1 {
+		t.Errorf("Short 的统计 = %+v", short)
+	}
+	if long.Lines != 10 || long.CodeLines != 10 || long.CommentLines != 0 || long.Score != 10 {
+		t.Errorf("Long 的统计 = %+v", long)
+	}
+	// 跨行的块注释与原始字符串的每一行都被计入
+	if start.Name != "Server.Start" || start.CodeLines != 4 || start.CommentLines != 3 || start.InlineLines != 2 || start.Comments != 2 {
+		t.Errorf("Server.Start 的统计 = %+v", start)
+	}
+
+	// 文件：代码19行，注释6行（其中一行同时有代码）
+	if fs.CodeLines != 19 || fs.CommentLines != 6 || fs.Comments != 5 || fs.Density != 0.24 {
+		t.Errorf("文件的统计 = %+v", fs.CommentMetrics)
+	}
+	// Short、Server serves requests、Start、server
+	if fs.LatinChars != 36 || fs.CJKChars != 16 {
+		t.Errorf("文字统计: 拉丁字母 %d, 中日韩文字 %d", fs.LatinChars, fs.CJKChars)
+	}
+
+	report := NewStatsReport([]FileStats{*fs})
+	if report.Functions[0].Name != "Long" {
+		t.Errorf("最缺少注释的函数 = %s, 期望 Long", report.Functions[0].Name)
+	}
+}
+
+func TestCommentStatsCorpus(t *testing.T) {
+	// 以realistic_*测试数据校准：注释最多的文件密度应明显更高
+	density := make(map[string]float64)
+	for _, name := range []string{"realistic_high_comment.go", "realistic_small.go"} {
+		fs, err := CommentStats(FromFile(filepath.Join("testdata", name)))
+		if err != nil {
+			t.Fatalf("统计 %s 失败: %v", name, err)
+		}
+		density[name] = fs.Density
+	}
+	if density["realistic_high_comment.go"] <= density["realistic_small.go"] {
+		t.Errorf("密度: %v, 期望 realistic_high_comment.go 更高", density)
+	}
+}
+
+func TestCommentStatsDirSkipsGenerated(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":    "package p\n\n// A 说明\nfunc A() {\n\t_ = 1\n}\n",
+		"a.pb.go": "// Code generated by protoc. DO NOT EDIT.\n\npackage p\n\nfunc B() {\n\t_ = 2\n}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", name, err)
+		}
+	}
+
+	report, err := NewExtractor(WithGenerated(generated.Skip)).CommentStatsDir(root)
+	if err != nil {
+		t.Fatalf("统计注释失败: %v", err)
+	}
+	if len(report.Files) != 1 || report.Files[0].File != "a.go" {
+		t.Errorf("统计的文件为 %+v，期望只有 a.go", report.Files)
+	}
+	if len(report.Functions) != 1 || report.Functions[0].Name != "A" {
+		t.Errorf("函数排名为 %+v，期望只有 A", report.Functions)
+	}
+	if len(report.Skipped) != 1 || report.Skipped[0] != "a.pb.go" {
+		t.Errorf("跳过的文件为 %v，期望 [a.pb.go]", report.Skipped)
+	}
+
+	if _, err := NewExtractor(WithGenerated(generated.Refuse)).CommentStatsDir(root); !errors.Is(err, generated.ErrGenerated) {
+		t.Errorf("拒绝处理生成文件时应返回ErrGenerated，实际为 %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package getcomments
+
+import (
+	"go/ast"
+	"go/token"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SymbolKey 返回记录在SymbolMap中的键：文件在子目录中时以目录为前缀，避免不同目录下同名包的符号冲突，
+// 例如 "cmd/findmain/main.MainEntry.Dir"；没有符号时返回Key()
+func (r CommentRecord) SymbolKey() string {
+	if r.Symbol == "" {
+		return r.Key()
+	}
+	if dir := path.Dir(r.File); dir != "." {
+		return dir + "/" + r.Symbol
+	}
+	return r.Symbol
+}
+
+// SymbolMap 将注释记录按符号与注释类型分组
+// 与按行号分组不同，代码上下移动后符号保持不变，适合比较不同版本之间每个符号的注释
+func (rs CommentRecords) SymbolMap() GroupedCommentsMap {
+	grouped := make(GroupedCommentsMap)
+	for _, r := range rs {
+		key := r.SymbolKey()
+		g := grouped[key]
+		if g == nil {
+			g = &GroupedComments{}
+			grouped[key] = g
+		}
+		g.add(r)
+	}
+	return grouped
+}
+
+// symbolIndex 按位置查找注释所属的符号，只沿包含该位置的节点向下查找，不为没有注释的语句构造符号
+// 符号的形式：
+//   - 包：getcomments
+//   - 函数与方法：getcomments.ExtractComments、main.(*RegistryController).run、main.Point.String
+//   - 类型、包级常量与变量：main.MainEntry、main.defaultTimeout
+//   - 结构体字段与接口方法：main.MainEntry.Dir，匿名结构体的字段带上外层字段名
+//   - 类型参数：main.List[T]、main.Map[K]，接口类型集合中的元素属于接口本身
+//   - 函数体内的语句：main.(*RegistryController).run#stmt3，嵌套的语句依次加上序号，如 #stmt3.2
+type symbolIndex struct {
+	file *ast.File
+	pkg  string
+}
+
+func newSymbolIndex(f *ast.File) *symbolIndex {
+	idx := &symbolIndex{file: f}
+	if f.Name != nil {
+		idx.pkg = f.Name.Name
+	}
+	return idx
+}
+
+// lookup 返回包含pos的最内层符号以及该符号对应的节点，不在任何声明中时返回包名与nil
+func (idx *symbolIndex) lookup(pos token.Pos) (string, ast.Node) {
+	decls := idx.file.Decls
+	i := sort.Search(len(decls), func(i int) bool { return decls[i].End() > pos })
+	if i == len(decls) || pos < decls[i].Pos() {
+		return idx.pkg, nil
+	}
+	switch d := decls[i].(type) {
+	case *ast.FuncDecl:
+		symbol := idx.qualify(funcSymbol(d))
+		if tp := d.Type.TypeParams; tp != nil && tp.Pos() <= pos && pos < tp.End() {
+			return typeParamSymbol(tp, pos, symbol, d)
+		}
+		if d.Body != nil {
+			return stmtSymbol(d.Body.List, pos, symbol+"#stmt", d)
+		}
+		return symbol, d
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			if spec.Pos() <= pos && pos < spec.End() {
+				return idx.specSymbol(spec, pos)
+			}
+		}
+	}
+	return idx.pkg, nil
+}
+
+// funcSymbol 返回函数名，方法带有接收者类型，指针接收者写作 (*T)
+func funcSymbol(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+	recvType := d.Recv.List[0].Type
+	recv := receiverTypeName(recvType)
+	if _, ok := recvType.(*ast.StarExpr); ok {
+		recv = "(*" + recv + ")"
+	}
+	return recv + "." + d.Name.Name
+}
+
+func (idx *symbolIndex) qualify(name string) string {
+	if idx.pkg == "" {
+		return name
+	}
+	return idx.pkg + "." + name
+}
+
+// specSymbol 返回类型、常量与变量中包含pos的符号，包括其中的字段和函数字面量中的语句
+func (idx *symbolIndex) specSymbol(spec ast.Spec, pos token.Pos) (string, ast.Node) {
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		symbol := idx.qualify(s.Name.Name)
+		if tp := s.TypeParams; tp != nil && tp.Pos() <= pos && pos < tp.End() {
+			return typeParamSymbol(tp, pos, symbol, s)
+		}
+		return fieldSymbol(s.Type, pos, symbol, s)
+	case *ast.ValueSpec:
+		if len(s.Names) == 0 {
+			return idx.pkg, nil
+		}
+		symbol := idx.qualify(s.Names[0].Name)
+		if s.Type != nil && s.Type.Pos() <= pos && pos < s.Type.End() {
+			return fieldSymbol(s.Type, pos, symbol, s)
+		}
+		// 包级函数字面量中的语句依次编号
+		var stmts []ast.Stmt
+		for _, value := range s.Values {
+			ast.Inspect(value, func(n ast.Node) bool {
+				if lit, ok := n.(*ast.FuncLit); ok {
+					stmts = append(stmts, lit.Body.List...)
+					return false
+				}
+				return true
+			})
+		}
+		return stmtSymbol(stmts, pos, symbol+"#stmt", s)
+	}
+	return idx.pkg, nil
+}
+
+// fieldSymbol 返回结构体字段与接口方法中包含pos的符号与节点，匿名结构体的字段递归查找；
+// 不在任何字段中时返回symbol与owner
+func fieldSymbol(expr ast.Expr, pos token.Pos, symbol string, owner ast.Node) (string, ast.Node) {
+	var fields *ast.FieldList
+	switch t := expr.(type) {
+	case *ast.StructType:
+		fields = t.Fields
+	case *ast.InterfaceType:
+		fields = t.Methods
+	case *ast.StarExpr:
+		return fieldSymbol(t.X, pos, symbol, owner)
+	case *ast.ArrayType:
+		return fieldSymbol(t.Elt, pos, symbol, owner)
+	case *ast.MapType:
+		return fieldSymbol(t.Value, pos, symbol, owner)
+	}
+	if fields == nil {
+		return symbol, owner
+	}
+	for _, field := range fields.List {
+		if pos < field.Pos() || pos >= field.End() {
+			continue
+		}
+		var name string
+		if len(field.Names) > 0 {
+			name = field.Names[0].Name
+		} else if ident := embeddedFieldIdent(field.Type); ident != nil {
+			name = ident.Name
+		} else {
+			return symbol, owner
+		}
+		return fieldSymbol(field.Type, pos, symbol+"."+name, field)
+	}
+	return symbol, owner
+}
+
+// typeParamSymbol 返回类型参数列表中包含pos的类型参数的符号与节点，如 main.List[T]，
+// 内联约束中的方法继续向下查找
+func typeParamSymbol(params *ast.FieldList, pos token.Pos, symbol string, owner ast.Node) (string, ast.Node) {
+	for _, field := range params.List {
+		if field.Pos() <= pos && pos < field.End() && len(field.Names) > 0 {
+			return fieldSymbol(field.Type, pos, symbol+"["+field.Names[0].Name+"]", field)
+		}
+	}
+	return symbol, owner
+}
+
+// stmtSymbol 返回语句列表中包含pos的语句的符号与节点，语句按顺序从1开始编号，嵌套的语句继续向下查找；
+// pos 不在任何语句中时返回去掉prefix中 "#stmt" 或 "." 后缀的外层符号与owner
+func stmtSymbol(stmts []ast.Stmt, pos token.Pos, prefix string, owner ast.Node) (string, ast.Node) {
+	i := sort.Search(len(stmts), func(i int) bool { return stmts[i].End() > pos })
+	if i == len(stmts) || pos < stmts[i].Pos() {
+		prefix = strings.TrimSuffix(prefix, "#stmt")
+		return strings.TrimSuffix(prefix, "."), owner
+	}
+	symbol := prefix + strconv.Itoa(i+1)
+	return stmtSymbol(childStmts(stmts[i]), pos, symbol+".", stmts[i])
+}
+
+// childStmts 返回语句直接包含的语句：代码块、case与select分支中的语句，
+// if-else 链的各个分支以及语句中函数字面量的语句按出现顺序排列
+func childStmts(stmt ast.Stmt) []ast.Stmt {
+	switch s := stmt.(type) {
+	case *ast.BlockStmt:
+		return s.List
+	case *ast.CaseClause:
+		return s.Body
+	case *ast.CommClause:
+		return s.Body
+	}
+	var children []ast.Stmt
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.BlockStmt:
+			children = append(children, node.List...)
+			return false
+		case *ast.CaseClause:
+			children = append(children, node.Body...)
+			return false
+		case *ast.CommClause:
+			children = append(children, node.Body...)
+			return false
+		}
+		return true
+	})
+	return children
+}
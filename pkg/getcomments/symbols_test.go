@@ -0,0 +1,131 @@
+package getcomments
+
+import (
+	"strings"
+	"testing"
+)
+
+const symbolsTestCode = `// Package demo 示例
+package demo
+
+// MainEntry 入口
+type MainEntry struct {
+	Dir string // 目录
+	// Options 选项
+	Options struct {
+		Debug bool // 调试
+	}
+	*Logger // 日志
+}
+
+// Runner 运行器
+type Runner interface {
+	Run() error // 运行
+}
+
+// defaultTimeout 默认超时
+const defaultTimeout = 10
+
+// RegistryController 控制器
+type RegistryController struct{}
+
+// run 运行
+func (c *RegistryController) run(items []string) {
+	// 第一条语句
+	n := 0
+	for _, item := range items {
+		// 循环体
+		n += len(item)
+		if n > 10 {
+			break // 提前结束
+		} else {
+			n-- // 否则
+		}
+	}
+	switch n {
+	case 1:
+		// 分支
+		n++
+	}
+	go func() {
+		println(n) // 闭包
+	}()
+}
+
+// String 值接收者
+func (e MainEntry) String() string { return e.Dir }
+
+// handler 包级函数字面量
+var handler = func() {
+	// 处理
+	println()
+}
+`
+
+func TestCommentSymbols(t *testing.T) {
+	records, err := ExtractCommentRecordsFrom(FromSource("demo.go", []byte(symbolsTestCode)))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	got := make(map[string]string)
+	for _, r := range records {
+		got[r.Text] = r.Symbol
+	}
+
+	want := map[string]string{
+		"Package demo 示例":     "demo",
+		"MainEntry 入口":        "demo.MainEntry",
+		"目录":                  "demo.MainEntry.Dir",
+		"Options 选项":          "demo.MainEntry.Options",
+		"调试":                  "demo.MainEntry.Options.Debug",
+		"日志":                  "demo.MainEntry.Logger",
+		"Runner 运行器":          "demo.Runner",
+		"运行":                  "demo.Runner.Run",
+		"defaultTimeout 默认超时": "demo.defaultTimeout",
+		"run 运行":              "demo.(*RegistryController).run",
+		"第一条语句":               "demo.(*RegistryController).run#stmt1",
+		"循环体":                 "demo.(*RegistryController).run#stmt2.1",
+		"提前结束":                "demo.(*RegistryController).run#stmt2.2.1",
+		"否则":                  "demo.(*RegistryController).run#stmt2.2.2",
+		"分支":                  "demo.(*RegistryController).run#stmt3.1.1",
+		"闭包":                  "demo.(*RegistryController).run#stmt4.1",
+		"String 值接收者":         "demo.MainEntry.String",
+		"handler 包级函数字面量":     "demo.handler",
+		"处理":                  "demo.handler#stmt1",
+	}
+	for text, symbol := range want {
+		if got[text] != symbol {
+			t.Errorf("注释 %q 的符号 = %q, 期望 %q", text, got[text], symbol)
+		}
+	}
+}
+
+func TestSymbolMapStable(t *testing.T) {
+	before, err := ExtractCommentRecordsFrom(FromSource("demo.go", []byte(symbolsTestCode)))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+	// 在文件开头插入代码后，行号全部变化，符号保持不变
+	shifted := strings.Replace(symbolsTestCode, "package demo\n", "package demo\n\nimport \"fmt\"\n\nvar _ = fmt.Sprint\n", 1)
+	after, err := ExtractCommentRecordsFrom(FromSource("demo.go", []byte(shifted)))
+	if err != nil {
+		t.Fatalf("提取注释失败: %v", err)
+	}
+
+	beforeMap, afterMap := before.SymbolMap(), after.SymbolMap()
+	if len(beforeMap) != len(afterMap) {
+		t.Fatalf("符号数量 %d -> %d", len(beforeMap), len(afterMap))
+	}
+	for symbol, g := range beforeMap {
+		if a := afterMap[symbol]; a == nil || strings.Join(a.Doc, "") != strings.Join(g.Doc, "") ||
+			strings.Join(a.Leading, "") != strings.Join(g.Leading, "") || strings.Join(a.Trailing, "") != strings.Join(g.Trailing, "") {
+			t.Errorf("符号 %s 的注释发生变化", symbol)
+		}
+	}
+
+	// 子目录中的文件以目录为前缀
+	r := CommentRecord{File: "cmd/findmain/main.go", Symbol: "main.MainEntry.Dir"}
+	if key := r.SymbolKey(); key != "cmd/findmain/main.MainEntry.Dir" {
+		t.Errorf("SymbolKey = %q", key)
+	}
+}
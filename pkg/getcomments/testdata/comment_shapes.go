@@ -0,0 +1,33 @@
+package testdata
+
+import "fmt"
+
+/*
+CommentShapes 跨行的块注释作为文档
+
+空行也属于同一条注释
+*/
+func CommentShapes() {
+	/* 跨行块注释
+	   结束于语句的上一行 */
+	x := 1
+	/* 结束于语句所在行
+	 */y := 2
+	z := x + y /* 块注释 */ // 同一行的第二条注释
+	/* 第一条 */ /* 第二条 */
+	fmt.Println(z)
+
+	/* 与语句之间
+	   隔着空行 */
+
+	fmt.Println(x)
+}
+
+// Shape 字段的多条注释
+type Shape struct {
+	Name string /* 名称 */ // 显示名称
+	Size int    // 大小
+	/* 颜色
+	   十六进制 */
+	Color string
+}
@@ -0,0 +1,63 @@
+package testdata
+
+import "sync"
+
+// Number 数值类型约束
+type Number interface {
+	~int | ~int64 // 整数
+	// 浮点数
+	~float32 | ~float64
+	String() string // 字符串表示
+}
+
+// Cache 泛型缓存
+type Cache[
+	K comparable, // 键
+	// 值
+	// 可以是任意类型
+	V any,
+] struct {
+	Store[K, V] // 嵌入的泛型存储
+	*sync.Mutex // 嵌入的互斥锁
+	// Config 配置
+	Config struct {
+		Name   string // 名称
+		Server struct {
+			// 地址
+			// 形如 host:port
+			Addr string
+		}
+	}
+	hooks []struct {
+		Name string // 钩子名称
+	}
+}
+
+// Sum 求和
+func Sum[
+	// 元素类型
+	T Number,
+](xs []T) T {
+	var total T
+	for _, x := range xs {
+		total += x // 累加
+	}
+	return total
+}
+
+// Get 读取缓存
+func (c *Cache[K, V]) Get(key K) (V, bool) { // 泛型接收者
+	var zero V
+	return zero, false
+}
+
+// Filter 过滤
+func Filter[T interface {
+	Keep() bool // 是否保留
+}](xs []T) []T {
+	return xs
+}
+
+var options struct {
+	Verbose bool // 输出详细信息
+}
@@ -0,0 +1,14 @@
+// 分组声明中的规格没有所在声明的文档注释时向上查找连续的注释行：
+// StrategyBounded 最多查找5行，StrategyContiguous 查找到空行为止
+package testdata
+
+var (
+	// 第1行
+	// 第2行
+	// 第3行
+	// 第4行
+	// 第5行
+	// 第6行
+	// 第7行
+	Limit = 1
+)
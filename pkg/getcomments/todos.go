@@ -0,0 +1,258 @@
+package getcomments
+
+import (
+	"errors"
+	"go/ast"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+// TodoMarkers 支持的待办标记
+var TodoMarkers = []string{"TODO", "FIXME", "HACK", "XXX", "BUG"}
+
+// Todo 注释中的一条待办标记
+type Todo struct {
+	File    string   `json:"file"`
+	Package string   `json:"package"`          // 文件所在目录；位于根目录时为包名
+	Pos     Position `json:"pos"`              // 标记在注释第一行时为注释的起始位置，在块注释后续行时为标记自身的位置
+	Marker  string   `json:"marker"`           // TODO、FIXME、HACK、XXX 或 BUG
+	Owner   string   `json:"owner,omitempty"`  // 负责人，即 TODO(owner) 中的 owner
+	Issues  []string `json:"issues,omitempty"` // 引用的问题，如 #123、org/repo#45
+	Text    string   `json:"text"`             // 标记之后的说明
+	Func    string   `json:"func,omitempty"`   // 所在的函数，如 run、(*Server).Serve
+	Line    int      `json:"line,omitempty"`   // 注释关联的代码行，未关联时为0
+	Node    NodeInfo `json:"node"`             // 注释关联的AST节点
+}
+
+// TodoResult 是目录模式下的待办标记扫描结果
+type TodoResult struct {
+	Root    string      `json:"root"`
+	Files   []string    `json:"files"`
+	Todos   []Todo      `json:"todos"`
+	Skipped []string    `json:"skipped,omitempty"` // 按generated.Skip跳过的生成文件
+	Errors  []FileError `json:"errors,omitempty"`
+}
+
+// TodoGroup 按某个字段分组的待办标记
+type TodoGroup struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+	Todos []Todo `json:"todos"`
+}
+
+var (
+	todoPattern  = regexp.MustCompile(`^(TODO|FIXME|HACK|XXX|BUG)(?:\(([^)]*)\))?(?::|\s|$)\s*(.*)$`)
+	issuePattern = regexp.MustCompile(`(?:[\w.-]+/[\w.-]+)?#\d+\b`)
+)
+
+// FindTodos 查找输入中的待办标记
+func FindTodos(in Input) ([]Todo, error) {
+	return defaultExtractor.FindTodos(in)
+}
+
+// FindTodosDir 按目录模式查找待办标记，pattern 的规则与ExtractDir相同
+func FindTodosDir(pattern string) (*TodoResult, error) {
+	return defaultExtractor.FindTodosDir(pattern)
+}
+
+// FindTodos 使用提取器的配置查找输入中的待办标记
+// 每条标记通过注释关联找到对应的代码行与AST节点，并记录所在的函数；按generated.Skip跳过的生成文件没有标记
+func (e *Extractor) FindTodos(in Input) ([]Todo, error) {
+	todos, _, err := e.findTodos(in)
+	return todos, err
+}
+
+// findTodos 查找输入中的待办标记，skip 表示输入是被跳过的生成文件
+func (e *Extractor) findTodos(in Input) (todos []Todo, skip bool, err error) {
+	parsed, skip, err := e.parseChecked(in)
+	if skip || err != nil {
+		return nil, skip, err
+	}
+	records := e.associate(in.Filename(), parsed)
+	associated := make(map[Position]CommentRecord, len(records))
+	for _, r := range records {
+		associated[r.Start] = r
+	}
+
+	pkg := path.Dir(in.Filename())
+	if pkg == "." {
+		pkg = parsed.File.Name.Name
+	}
+
+	for _, cg := range parsed.File.Comments {
+		for _, c := range cg.List {
+			start := parsed.Fset.Position(c.Pos())
+			record, hasRecord := associated[Position{Line: start.Line, Column: start.Column}]
+			for i, line := range strings.Split(c.Text, "\n") {
+				todo, ok := parseTodoLine(commentLineText(line, i == 0))
+				if !ok {
+					continue
+				}
+				todo.File = in.Filename()
+				todo.Package = pkg
+				todo.Pos = Position{Line: start.Line + i, Column: start.Column}
+				if i > 0 {
+					// 块注释的后续行从所在行的行首开始，列号按标记在该行中的偏移计算
+					todo.Pos.Column = strings.Index(line, todo.Marker) + 1
+				}
+				if hasRecord {
+					todo.Line = record.Line
+					todo.Node = record.Node
+				}
+				todo.Func = enclosingFunc(parsed.File, c)
+				todos = append(todos, todo)
+			}
+		}
+	}
+	return todos, false, nil
+}
+
+// FindTodosDir 使用提取器的配置按目录模式查找待办标记，跳过的生成文件记录在Skipped中，
+// 拒绝处理生成文件时返回错误
+func (e *Extractor) FindTodosDir(pattern string) (*TodoResult, error) {
+	dir, err := ListDir(pattern)
+	if err != nil {
+		return nil, err
+	}
+	result := &TodoResult{Root: dir.Root, Errors: dir.Errors}
+	for _, in := range dir.Inputs {
+		todos, skip, err := e.findTodos(in)
+		switch {
+		case errors.Is(err, generated.ErrGenerated):
+			return nil, err
+		case err != nil:
+			result.Errors = append(result.Errors, FileError{File: in.Filename(), Err: err.Error()})
+			continue
+		case skip:
+			result.Skipped = append(result.Skipped, in.Filename())
+			continue
+		}
+		result.Files = append(result.Files, in.Filename())
+		result.Todos = append(result.Todos, todos...)
+	}
+	return result, nil
+}
+
+// commentLineText 去除注释符号，返回注释中一行的文本
+func commentLineText(line string, first bool) string {
+	if first {
+		if strings.HasPrefix(line, "//") {
+			return strings.TrimSpace(line[2:])
+		}
+		line = strings.TrimPrefix(line, "/*")
+	}
+	line = strings.TrimSuffix(strings.TrimSpace(line), "*/")
+	// 块注释中常见的 " * " 前缀
+	return strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "*"))
+}
+
+// parseTodoLine 解析以待办标记开头的一行注释文本
+func parseTodoLine(text string) (Todo, bool) {
+	m := todoPattern.FindStringSubmatch(text)
+	if m == nil {
+		return Todo{}, false
+	}
+	todo := Todo{Marker: m[1], Text: strings.TrimSpace(m[3])}
+	if owner := strings.TrimSpace(m[2]); owner != "" {
+		// 括号中只有问题引用时（如 TODO(#123)）不作为负责人
+		if issuePattern.FindString(owner) == owner {
+			todo.Issues = append(todo.Issues, owner)
+		} else {
+			todo.Owner = owner
+		}
+	}
+	todo.Issues = append(todo.Issues, issuePattern.FindAllString(todo.Text, -1)...)
+	return todo, true
+}
+
+// enclosingFunc 返回注释所在的函数名，函数的文档注释也属于该函数，不在函数中时返回空字符串
+func enclosingFunc(f *ast.File, c *ast.Comment) string {
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		start := funcDecl.Pos()
+		if funcDecl.Doc != nil {
+			start = funcDecl.Doc.Pos()
+		}
+		if c.Pos() >= start && c.End() <= funcDecl.End() {
+			return FuncName(funcDecl)
+		}
+	}
+	return ""
+}
+
+// FuncName 返回函数声明的名称，方法带有接收者类型，如 (*Server).Serve、List.Len
+// 泛型接收者的类型参数会被省略
+func FuncName(funcDecl *ast.FuncDecl) string {
+	if funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+		return funcDecl.Name.Name
+	}
+	recv := funcDecl.Recv.List[0].Type
+	name := receiverTypeName(recv)
+	if name == "" {
+		name = "?"
+	}
+	if _, ok := recv.(*ast.StarExpr); ok {
+		return "(*" + name + ")." + funcDecl.Name.Name
+	}
+	return name + "." + funcDecl.Name.Name
+}
+
+// GroupTodos 按 owner、package 或 marker 对待办标记分组，分组按键排序
+// 没有负责人的标记归入键为空字符串的分组
+func GroupTodos(todos []Todo, by string) []TodoGroup {
+	index := make(map[string]int)
+	var groups []TodoGroup
+	for _, todo := range todos {
+		var key string
+		switch by {
+		case "owner":
+			key = todo.Owner
+		case "package":
+			key = todo.Package
+		default:
+			key = todo.Marker
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, TodoGroup{Key: key})
+		}
+		groups[i].Todos = append(groups[i].Todos, todo)
+		groups[i].Count++
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
+
+// NewTodos 返回current中不在baseline里的待办标记
+// 标记按文件、标记、负责人与说明匹配，不比较行号，因此代码移动不会被视为新增
+func NewTodos(current, baseline []Todo) []Todo {
+	seen := make(map[string]int)
+	for _, todo := range baseline {
+		seen[todoIdentity(todo)]++
+	}
+	var added []Todo
+	for _, todo := range current {
+		id := todoIdentity(todo)
+		if seen[id] > 0 {
+			seen[id]--
+			continue
+		}
+		added = append(added, todo)
+	}
+	return added
+}
+
+func todoIdentity(todo Todo) string {
+	return strings.Join([]string{todo.File, todo.Marker, todo.Owner, todo.Text}, "\x00")
+}
@@ -0,0 +1,133 @@
+package getcomments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/monshunter/ast-practice/pkg/generated"
+)
+
+const todoTestCode = `package p
+
+// TODO(alice): 支持更多格式 #12
+type Server struct{}
+
+// Serve 启动服务
+// FIXME: 处理超时 org/repo#7
+func (s *Server) Serve() {
+	x := 1 // HACK 临时绕过
+	/* XXX(#34): 需要重构 */
+	_ = x
+}
+
+// BUG(bob): 泛型接收者
+func (l List[T]) Len() int { return 0 }
+
+// 这里提到TODO但不是标记
+var v int
+`
+
+func TestFindTodos(t *testing.T) {
+	todos, err := FindTodos(FromSource("p.go", []byte(todoTestCode)))
+	if err != nil {
+		t.Fatalf("查找待办标记失败: %v", err)
+	}
+
+	want := []Todo{
+		{Pos: Position{3, 1}, Marker: "TODO", Owner: "alice", Issues: []string{"#12"}, Text: "支持更多格式 #12", Line: 4, Node: NodeInfo{"TypeSpec", "Server"}},
+		{Pos: Position{7, 1}, Marker: "FIXME", Issues: []string{"org/repo#7"}, Text: "处理超时 org/repo#7", Func: "(*Server).Serve", Line: 8, Node: NodeInfo{"FuncDecl", "Serve"}},
+		{Pos: Position{9, 9}, Marker: "HACK", Text: "临时绕过", Func: "(*Server).Serve", Line: 9, Node: NodeInfo{"AssignStmt", ""}},
+		{Pos: Position{10, 2}, Marker: "XXX", Issues: []string{"#34"}, Text: "需要重构", Func: "(*Server).Serve", Line: 11, Node: NodeInfo{"AssignStmt", ""}},
+		{Pos: Position{14, 1}, Marker: "BUG", Owner: "bob", Text: "泛型接收者", Func: "List.Len", Line: 15, Node: NodeInfo{"FuncDecl", "Len"}},
+	}
+	for i := range want {
+		want[i].File = "p.go"
+		want[i].Package = "p"
+	}
+	if !reflect.DeepEqual(todos, want) {
+		t.Errorf("待办标记为\n%+v\n期望\n%+v", todos, want)
+	}
+}
+
+func TestFindTodosBlockColumns(t *testing.T) {
+	code := "package p\n\n/*\n * 说明\n * TODO: 第二行的标记\n */\nfunc f() {\n\t/* 第一行\n\t   FIXME(carol): 缩进的标记 */\n\t_ = 1\n}\n"
+	todos, err := FindTodos(FromSource("p.go", []byte(code)))
+	if err != nil {
+		t.Fatalf("查找待办标记失败: %v", err)
+	}
+
+	// 块注释后续行的列号是标记在该行中的位置，而不是注释的起始列
+	want := []Position{{5, 4}, {9, 5}}
+	if len(todos) != len(want) {
+		t.Fatalf("待办标记数量为 %d，期望 %d: %+v", len(todos), len(want), todos)
+	}
+	for i, todo := range todos {
+		if todo.Pos != want[i] {
+			t.Errorf("%s 的位置为 %+v，期望 %+v", todo.Marker, todo.Pos, want[i])
+		}
+	}
+}
+
+func TestGroupTodosAndNewTodos(t *testing.T) {
+	todos := []Todo{
+		{File: "a.go", Marker: "FIXME", Owner: "bob", Text: "一"},
+		{File: "a.go", Marker: "TODO", Text: "二"},
+		{File: "b.go", Marker: "FIXME", Owner: "alice", Text: "三"},
+	}
+
+	groups := GroupTodos(todos, "owner")
+	var keys []string
+	for _, g := range groups {
+		keys = append(keys, g.Key)
+	}
+	if want := []string{"", "alice", "bob"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("分组键为 %q, 期望 %q", keys, want)
+	}
+	if groups := GroupTodos(todos, "marker"); groups[0].Key != "FIXME" || groups[0].Count != 2 {
+		t.Errorf("按标记分组结果错误: %+v", groups)
+	}
+
+	// 行号变化不影响匹配
+	baseline := []Todo{{File: "a.go", Marker: "FIXME", Owner: "bob", Text: "一", Pos: Position{Line: 99}}}
+	added := NewTodos(todos, baseline)
+	if len(added) != 2 || added[0].Text != "二" || added[1].Text != "三" {
+		t.Errorf("新增的待办标记为 %+v", added)
+	}
+}
+
+func TestFindTodosDirGenerated(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"a.go":    "package p\n\n// TODO: 手写代码\nfunc A() {}\n",
+		"a.pb.go": "// Code generated by protoc. DO NOT EDIT.\n\npackage p\n\n// TODO: 生成代码\nfunc B() {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatalf("无法写入文件 %s: %v", name, err)
+		}
+	}
+
+	result, err := NewExtractor(WithGenerated(generated.Skip)).FindTodosDir(root)
+	if err != nil {
+		t.Fatalf("查找待办标记失败: %v", err)
+	}
+	if len(result.Todos) != 1 || result.Todos[0].File != "a.go" {
+		t.Errorf("待办标记为 %+v，期望只有 a.go 中的一条", result.Todos)
+	}
+	if !reflect.DeepEqual(result.Skipped, []string{"a.pb.go"}) {
+		t.Errorf("跳过的文件为 %v，期望 [a.pb.go]", result.Skipped)
+	}
+
+	// 单个文件同样按处理方式跳过
+	todos, err := NewExtractor(WithGenerated(generated.Skip)).FindTodos(FromSource("a.pb.go", []byte(files["a.pb.go"])))
+	if err != nil || len(todos) != 0 {
+		t.Errorf("跳过的生成文件中不应有待办标记: %+v, %v", todos, err)
+	}
+
+	if _, err := NewExtractor(WithGenerated(generated.Refuse)).FindTodosDir(root); !errors.Is(err, generated.ErrGenerated) {
+		t.Errorf("拒绝处理生成文件时应返回ErrGenerated，实际为 %v", err)
+	}
+}
@@ -0,0 +1,490 @@
+package getcomments
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Translator 将一批注释文本翻译为目标语言，返回的结果与输入一一对应
+// 文本中形如 {{0}} 的占位符代表需要原样保留的标识符，实现必须保留它们
+type Translator interface {
+	Translate(ctx context.Context, texts []string) ([]string, error)
+}
+
+// GlossaryTranslator 基于本地词汇表的翻译器
+// 整句在词汇表中时直接使用对应的译文，否则从左到右按最长匹配逐词替换，没有匹配的部分保持原样
+type GlossaryTranslator struct {
+	entries map[string]string
+	keys    []string // 按长度从长到短排列，用于最长匹配
+}
+
+// NewGlossaryTranslator 使用原文到译文的映射创建翻译器
+func NewGlossaryTranslator(entries map[string]string) *GlossaryTranslator {
+	g := &GlossaryTranslator{entries: make(map[string]string, len(entries))}
+	for source, target := range entries {
+		if source = strings.TrimSpace(source); source != "" {
+			g.entries[source] = target
+			g.keys = append(g.keys, source)
+		}
+	}
+	sort.Slice(g.keys, func(i, j int) bool {
+		if len(g.keys[i]) != len(g.keys[j]) {
+			return len(g.keys[i]) > len(g.keys[j])
+		}
+		return g.keys[i] < g.keys[j]
+	})
+	return g
+}
+
+// LoadGlossary 从JSON文件加载词汇表，文件内容为 {"原文": "译文"} 形式的对象
+func LoadGlossary(path string) (*GlossaryTranslator, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取词汇表失败: %v", err)
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("解析词汇表失败: %v", err)
+	}
+	return NewGlossaryTranslator(entries), nil
+}
+
+// 中文标点及其对应的英文标点
+var punctuationReplacer = strings.NewReplacer(
+	"，", ", ", "。", ". ", "：", ": ", "；", "; ", "！", "! ", "？", "? ",
+	"（", " (", "）", ") ", "、", ", ", "“", `"`, "”", `"`,
+)
+
+// Translate 实现Translator接口
+func (g *GlossaryTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	results := make([]string, len(texts))
+	for i, text := range texts {
+		if target, ok := g.entries[strings.TrimSpace(text)]; ok {
+			results[i] = target
+			continue
+		}
+		results[i] = g.replace(text)
+	}
+	return results, nil
+}
+
+// replace 按最长匹配替换词汇表中的词，并在译文与相邻的文字之间补充空格
+func (g *GlossaryTranslator) replace(text string) string {
+	var b strings.Builder
+	replaced := false // 上一段输出是否为译文
+	for i := 0; i < len(text); {
+		matched := ""
+		for _, key := range g.keys {
+			if strings.HasPrefix(text[i:], key) {
+				matched = key
+				break
+			}
+		}
+		if matched == "" {
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if replaced && !unicode.IsSpace(r) && (!unicode.IsPunct(r) || isOpenPunct(r)) {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			i += size
+			replaced = false
+			continue
+		}
+		target := g.entries[matched]
+		first, _ := utf8.DecodeRuneInString(target)
+		last, _ := utf8.DecodeLastRuneInString(b.String())
+		if b.Len() > 0 && !unicode.IsSpace(last) && !unicode.IsPunct(first) && !isOpenPunct(last) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(target)
+		i += len(matched)
+		replaced = true
+	}
+	text = punctuationReplacer.Replace(b.String())
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func isOpenPunct(r rune) bool {
+	return unicode.Is(unicode.Ps, r) || r == '"' || r == '\''
+}
+
+// HTTPTranslator 通过HTTP JSON接口翻译的翻译器
+// 请求为 POST {"source": "zh", "target": "en", "texts": [...]}，响应为 {"texts": [...]}
+type HTTPTranslator struct {
+	URL    string       // 翻译服务地址
+	Source string       // 源语言
+	Target string       // 目标语言
+	Client *http.Client // 为nil时使用http.DefaultClient
+}
+
+type translateRequest struct {
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Texts  []string `json:"texts"`
+}
+
+type translateResponse struct {
+	Texts []string `json:"texts"`
+}
+
+// Translate 实现Translator接口
+func (h *HTTPTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	body, err := json.Marshal(translateRequest{Source: h.Source, Target: h.Target, Texts: texts})
+	if err != nil {
+		return nil, fmt.Errorf("序列化翻译请求失败: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建翻译请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求翻译服务失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取翻译结果失败: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("翻译服务返回错误状态 %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var result translateResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析翻译结果失败: %v", err)
+	}
+	if len(result.Texts) != len(texts) {
+		return nil, fmt.Errorf("翻译结果数量不一致: 请求 %d 条，返回 %d 条", len(texts), len(result.Texts))
+	}
+	return result.Texts, nil
+}
+
+// CachingTranslator 缓存已翻译文本的翻译器，只把没有缓存的文本交给内部翻译器
+// 可以被多个goroutine并发使用
+type CachingTranslator struct {
+	translator Translator
+	mu         sync.Mutex
+	entries    map[string]string
+}
+
+// NewCachingTranslator 为翻译器增加缓存
+func NewCachingTranslator(t Translator) *CachingTranslator {
+	return &CachingTranslator{translator: t, entries: make(map[string]string)}
+}
+
+// Translate 实现Translator接口
+func (c *CachingTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	results := make([]string, len(texts))
+	var missing []string
+	var missingIndex []int
+	queued := make(map[string]bool)
+
+	c.mu.Lock()
+	for i, text := range texts {
+		if target, ok := c.entries[text]; ok {
+			results[i] = target
+			continue
+		}
+		missingIndex = append(missingIndex, i)
+		if !queued[text] {
+			queued[text] = true
+			missing = append(missing, text)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(missing) == 0 {
+		return results, nil
+	}
+	translated, err := c.translator.Translate(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(translated) != len(missing) {
+		return nil, fmt.Errorf("翻译结果数量不一致: 请求 %d 条，返回 %d 条", len(missing), len(translated))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, text := range missing {
+		c.entries[text] = translated[i]
+	}
+	for _, i := range missingIndex {
+		results[i] = c.entries[texts[i]]
+	}
+	return results, nil
+}
+
+// Len 返回缓存的条目数
+func (c *CachingTranslator) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Load 从JSON文件加载之前保存的缓存，文件不存在时不报错
+func (c *CachingTranslator) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取翻译缓存失败: %v", err)
+	}
+	var entries map[string]string
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("解析翻译缓存失败: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for source, target := range entries {
+		c.entries[source] = target
+	}
+	return nil
+}
+
+// Save 将缓存保存为JSON文件
+func (c *CachingTranslator) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.entries, "", "    ")
+	c.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("序列化翻译缓存失败: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("保存翻译缓存失败: %v", err)
+	}
+	return nil
+}
+
+// TranslateComments 翻译输入中的所有注释，并把译文写回源代码
+func TranslateComments(ctx context.Context, in Input, t Translator) (*ApplyResult, error) {
+	return defaultExtractor.TranslateComments(ctx, in, t)
+}
+
+var (
+	// 需要原样保留的片段：反引号包围的代码、URL、形如标识符的单词
+	protectedPattern = regexp.MustCompile("`[^`]*`|https?://\\S+|[A-Za-z_][A-Za-z0-9_]*(?:\\.[A-Za-z_][A-Za-z0-9_]*)*(?:\\(\\))?")
+	placeholderRegex = regexp.MustCompile(`\{\{\s*(\d+)\s*\}\}`)
+)
+
+// pendingTranslation 一条等待翻译的注释
+type pendingTranslation struct {
+	record    CommentRecord
+	lines     []commentLine
+	index     []int      // 每一行在待翻译文本中的下标，-1表示该行不需要翻译
+	protected [][]string // 每一行被占位符替换的片段
+}
+
+// commentLine 注释中的一行，译文只替换text部分
+type commentLine struct {
+	prefix string // 注释符号及缩进，如 "// "、"/* "、" * "
+	text   string
+	trail  string // 行尾空白，最后一行还包括块注释的 */
+}
+
+// TranslateComments 使用提取器的配置翻译输入中的注释，并把译文写回源代码
+// 编译器与检查工具指令以及不包含非ASCII字符的注释不会被翻译；
+// 注释中出现的代码标识符（文件中使用的名称、带点号或括号的名称）、反引号中的代码与URL
+// 会替换为占位符后再交给翻译器，翻译后原样恢复。块注释逐行翻译，保持行数不变。
+// 丢失占位符的译文不会被写回，而是记录在Conflicts中
+func (e *Extractor) TranslateComments(ctx context.Context, in Input, t Translator) (*ApplyResult, error) {
+	parsed, err := e.parse(in)
+	if err != nil {
+		return nil, err
+	}
+	idents := make(map[string]bool)
+	ast.Inspect(parsed.File, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			idents[ident.Name] = true
+		}
+		return true
+	})
+
+	var texts []string
+	var pending []*pendingTranslation
+	for _, cg := range parsed.File.Comments {
+		for _, c := range cg.List {
+			if IsDirective(c.Text) || isASCII(c.Text) {
+				continue
+			}
+			start, end := parsed.Fset.Position(c.Pos()), parsed.Fset.Position(c.End())
+			p := &pendingTranslation{
+				record: CommentRecord{
+					File:  in.Filename(),
+					Start: Position{Line: start.Line, Column: start.Column},
+					End:   Position{Line: end.Line, Column: end.Column},
+					Raw:   c.Text,
+					Block: strings.HasPrefix(c.Text, "/*"),
+				},
+				lines: splitCommentLines(c.Text),
+			}
+			for _, line := range p.lines {
+				if isASCII(line.text) {
+					p.index = append(p.index, -1)
+					p.protected = append(p.protected, nil)
+					continue
+				}
+				masked, protected := maskIdentifiers(line.text, idents)
+				p.index = append(p.index, len(texts))
+				p.protected = append(p.protected, protected)
+				texts = append(texts, masked)
+			}
+			pending = append(pending, p)
+		}
+	}
+
+	result := &ApplyResult{Source: parsed.Content}
+	if len(texts) == 0 {
+		return result, nil
+	}
+	translated, err := t.Translate(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("翻译注释失败: %v", err)
+	}
+	if len(translated) != len(texts) {
+		return nil, fmt.Errorf("翻译结果数量不一致: 请求 %d 条，返回 %d 条", len(texts), len(translated))
+	}
+
+	var changes []commentEdit
+	for _, p := range pending {
+		raw, reason := p.rebuild(translated)
+		if reason == "" {
+			var change commentEdit
+			if change, reason = newCommentEdit(parsed, p.record, raw); reason == "" {
+				changes = append(changes, change)
+				continue
+			}
+		}
+		result.conflict(fmt.Sprintf("%s:%d:%d", p.record.File, p.record.Start.Line, p.record.Start.Column), reason)
+	}
+	if err := result.apply(parsed, changes); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// splitCommentLines 将注释拆分为行，每行分为前缀、正文与行尾三部分
+func splitCommentLines(raw string) []commentLine {
+	if strings.HasPrefix(raw, "//") {
+		text := strings.TrimLeft(raw[2:], " \t")
+		return []commentLine{{prefix: raw[:len(raw)-len(text)], text: text}}
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(raw, "/*"), "*/")
+	parts := strings.Split(body, "\n")
+	lines := make([]commentLine, len(parts))
+	for i, part := range parts {
+		text := strings.TrimLeft(part, " \t")
+		// 行首的 * 后跟空白或位于行尾时属于前缀，如 " * 说明"
+		if i > 0 && strings.HasPrefix(text, "*") && (len(text) == 1 || text[1] == ' ' || text[1] == '\t') {
+			text = strings.TrimLeft(text[1:], " \t")
+		}
+		trimmed := strings.TrimRight(text, " \t")
+		lines[i] = commentLine{
+			prefix: part[:len(part)-len(text)],
+			text:   trimmed,
+			trail:  text[len(trimmed):],
+		}
+	}
+	lines[0].prefix = "/*" + lines[0].prefix
+	lines[len(lines)-1].trail += "*/"
+	return lines
+}
+
+// rebuild 用译文重新拼出注释，失败时返回原因
+func (p *pendingTranslation) rebuild(translated []string) (string, string) {
+	var b strings.Builder
+	for i, line := range p.lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		text := line.text
+		if p.index[i] >= 0 {
+			var ok bool
+			if text, ok = unmaskIdentifiers(translated[p.index[i]], p.protected[i]); !ok {
+				return "", "翻译结果丢失了需要保留的标识符"
+			}
+			text = strings.Join(strings.Fields(text), " ")
+			if p.record.Block && strings.Contains(text, "*/") {
+				return "", "翻译结果包含 */"
+			}
+		}
+		b.WriteString(line.prefix)
+		b.WriteString(text)
+		b.WriteString(line.trail)
+	}
+	return b.String(), ""
+}
+
+// maskIdentifiers 把需要保留的片段替换为 {{n}} 占位符
+func maskIdentifiers(text string, idents map[string]bool) (string, []string) {
+	var protected []string
+	masked := protectedPattern.ReplaceAllStringFunc(text, func(s string) string {
+		if !isProtected(s, idents) {
+			return s
+		}
+		protected = append(protected, s)
+		return "{{" + strconv.Itoa(len(protected)-1) + "}}"
+	})
+	return masked, protected
+}
+
+// isProtected 判断匹配到的片段是否需要保留
+func isProtected(s string, idents map[string]bool) bool {
+	if strings.HasPrefix(s, "`") || strings.HasPrefix(s, "http") && strings.Contains(s, "://") {
+		return true
+	}
+	if strings.ContainsAny(s, ".()") {
+		return true
+	}
+	return idents[s]
+}
+
+// unmaskIdentifiers 恢复占位符，任一占位符丢失时返回false
+func unmaskIdentifiers(text string, protected []string) (string, bool) {
+	seen := make([]bool, len(protected))
+	text = placeholderRegex.ReplaceAllStringFunc(text, func(s string) string {
+		n, _ := strconv.Atoi(placeholderRegex.FindStringSubmatch(s)[1])
+		if n >= len(protected) {
+			return s
+		}
+		seen[n] = true
+		return protected[n]
+	})
+	for _, ok := range seen {
+		if !ok {
+			return "", false
+		}
+	}
+	return text, true
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+package getcomments
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const translateTestCode = `package p
+
+//go:generate stringer -type=Color
+// Color 颜色
+type Color int
+
+// Paint 使用 ` + "`fmt.Println`" + ` 上色，参考 https://example.com/颜色
+func Paint(c Color) {
+	/* 定义变量
+	 * 两个变量 */
+	x := 1 // 打印 x
+	// English only
+	println(x)
+}
+`
+
+var testGlossary = map[string]string{
+	"颜色":  "color",
+	"上色":  "paints",
+	"使用":  "uses",
+	"参考":  "see",
+	"定义":  "define",
+	"变量":  "variable",
+	"两个":  "two",
+	"打印":  "print",
+	"示例句": "An example sentence.",
+}
+
+func TestGlossaryTranslator(t *testing.T) {
+	g := NewGlossaryTranslator(testGlossary)
+	got, err := g.Translate(context.Background(), []string{"示例句", "定义两个变量，打印{{0}}", "未知的词"})
+	if err != nil {
+		t.Fatalf("翻译失败: %v", err)
+	}
+	want := []string{"An example sentence.", "define two variable, print {{0}}", "未知的词"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("翻译结果为 %q, 期望 %q", got, want)
+	}
+}
+
+func TestTranslateComments(t *testing.T) {
+	result, err := TranslateComments(context.Background(), FromSource("p.go", []byte(translateTestCode)), NewGlossaryTranslator(testGlossary))
+	if err != nil {
+		t.Fatalf("翻译注释失败: %v", err)
+	}
+	want := strings.NewReplacer(
+		"// Color 颜色", "// Color color",
+		"// Paint 使用 `fmt.Println` 上色，参考 https://example.com/颜色", "// Paint uses `fmt.Println` paints, see https://example.com/颜色",
+		"/* 定义变量\n\t * 两个变量 */", "/* define variable\n\t * two variable */",
+		"// 打印 x", "// print x",
+	).Replace(translateTestCode)
+	if string(result.Source) != want {
+		t.Errorf("翻译后的代码为\n%s\n期望\n%s", result.Source, want)
+	}
+	if result.Applied != 4 || len(result.Conflicts) != 0 {
+		t.Errorf("applied=%d conflicts=%v", result.Applied, result.Conflicts)
+	}
+}
+
+// dropPlaceholders 返回的译文丢失了占位符
+type dropPlaceholders struct{}
+
+func (dropPlaceholders) Translate(ctx context.Context, texts []string) ([]string, error) {
+	results := make([]string, len(texts))
+	for i := range texts {
+		results[i] = "translated"
+	}
+	return results, nil
+}
+
+func TestTranslateCommentsLostIdentifiers(t *testing.T) {
+	result, err := TranslateComments(context.Background(), FromSource("p.go", []byte(translateTestCode)), dropPlaceholders{})
+	if err != nil {
+		t.Fatalf("翻译注释失败: %v", err)
+	}
+	// 只有 "定义变量 两个变量" 不包含需要保留的标识符
+	if result.Applied != 1 || len(result.Conflicts) != 3 {
+		t.Errorf("applied=%d conflicts=%v", result.Applied, result.Conflicts)
+	}
+	if !strings.Contains(string(result.Source), "// Color 颜色") {
+		t.Errorf("丢失标识符的译文不应写回:\n%s", result.Source)
+	}
+}
+
+// countingTranslator 记录每次收到的文本
+type countingTranslator struct {
+	calls [][]string
+}
+
+func (c *countingTranslator) Translate(ctx context.Context, texts []string) ([]string, error) {
+	c.calls = append(c.calls, texts)
+	results := make([]string, len(texts))
+	for i, text := range texts {
+		results[i] = strings.ToUpper(text)
+	}
+	return results, nil
+}
+
+func TestCachingTranslator(t *testing.T) {
+	inner := &countingTranslator{}
+	c := NewCachingTranslator(inner)
+	ctx := context.Background()
+
+	got, err := c.Translate(ctx, []string{"a", "b", "a"})
+	if err != nil || !reflect.DeepEqual(got, []string{"A", "B", "A"}) {
+		t.Fatalf("翻译结果为 %q, %v", got, err)
+	}
+	got, err = c.Translate(ctx, []string{"b", "c"})
+	if err != nil || !reflect.DeepEqual(got, []string{"B", "C"}) {
+		t.Fatalf("翻译结果为 %q, %v", got, err)
+	}
+	if want := [][]string{{"a", "b"}, {"c"}}; !reflect.DeepEqual(inner.calls, want) {
+		t.Errorf("内部翻译器收到 %q, 期望 %q", inner.calls, want)
+	}
+
+	path := t.TempDir() + "/cache.json"
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := NewCachingTranslator(&countingTranslator{})
+	if err := reloaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len() != 3 {
+		t.Errorf("加载后的缓存条目数为 %d, 期望 3", reloaded.Len())
+	}
+}
+
+func TestHTTPTranslator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req translateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Source != "zh" || req.Target != "en" {
+			http.Error(w, "错误的请求", http.StatusBadRequest)
+			return
+		}
+		resp := translateResponse{}
+		for _, text := range req.Texts {
+			resp.Texts = append(resp.Texts, "en:"+text)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	h := &HTTPTranslator{URL: server.URL, Source: "zh", Target: "en"}
+	got, err := h.Translate(context.Background(), []string{"你好", "{{0}} 世界"})
+	if err != nil {
+		t.Fatalf("翻译失败: %v", err)
+	}
+	if want := []string{"en:你好", "en:{{0}} 世界"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("翻译结果为 %q, 期望 %q", got, want)
+	}
+
+	h.Source = "fr"
+	if _, err := h.Translate(context.Background(), []string{"你好"}); err == nil {
+		t.Error("服务返回错误状态时应返回错误")
+	}
+}